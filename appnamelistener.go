@@ -1,21 +1,56 @@
 package axmlParser
 
 type AppNameListener struct {
-	PackageName      string
-	VersionName      string
-	VersionCode      string
-	ActivityName     string
-	tempActivityName string
-	findMainActivity bool
+	PackageName  string
+	VersionName  string
+	VersionCode  string
+	ActivityName string
+	// Label is the application label, a resource id unless the parser
+	// resolves references
+	Label string
+	// Err is set when the manifest does not fit the AndroidManifest model,
+	// ActivityName and Label being then read from the document as is
+	Err error
+
+	// tree keeps the document to find the launcher activity at its end
+	tree TreeListener
 }
 
 func (listener *AppNameListener) StartDocument() {
+	listener.tree.StartDocument()
 }
 
 /**
  * Receive notification of the end of a document.
  */
 func (listener *AppNameListener) EndDocument() {
+	doc := listener.tree.document()
+	manifest, err := NewAndroidManifest(doc)
+	if err != nil {
+		listener.Err = err
+		if app := doc.Find("application"); app != nil {
+			listener.Label = app.AttrValue(ANDROID_NAMESPACE, "label")
+		}
+		listener.ActivityName = mainActivityName(doc, listener.PackageName)
+		return
+	}
+	listener.Label = manifest.Application.Label
+	if launcher := manifest.LauncherActivity(); launcher != nil {
+		listener.ActivityName = launcher.Name
+	}
+}
+
+// mainActivityName returns the class name of the first activity of doc
+// with a MAIN action, or "".
+func mainActivityName(doc *Document, pkg string) string {
+	for _, activity := range doc.FindAll("application/activity") {
+		for _, action := range activity.FindAll("intent-filter/action") {
+			if action.AttrValue(ANDROID_NAMESPACE, "name") == ACTION_MAIN {
+				return ResolveClassName(pkg, activity.AttrValue(ANDROID_NAMESPACE, "name"))
+			}
+		}
+	}
+	return ""
 }
 
 /**
@@ -28,6 +63,7 @@ func (listener *AppNameListener) EndDocument() {
  *            the Namespace URI the prefix is mapped to
  */
 func (listener *AppNameListener) StartPrefixMapping(prefix, uri string) {
+	listener.tree.StartPrefixMapping(prefix, uri)
 }
 
 /**
@@ -61,9 +97,7 @@ func (listener *AppNameListener) EndPrefixMapping(prefix, uri string) {}
  */
 func (listener *AppNameListener) StartElement(uri, localName, qName string,
 	attrs []*Attribute) {
-	if listener.findMainActivity {
-		return
-	}
+	listener.tree.StartElement(uri, localName, qName, attrs)
 
 	if localName == "manifest" {
 		for _, attr := range attrs {
@@ -79,32 +113,6 @@ func (listener *AppNameListener) StartElement(uri, localName, qName string,
 				break
 			}
 		}
-		return
-	}
-
-	if localName == "activity" {
-		for _, attr := range attrs {
-			if attr.Name == "name" && attr.Prefix == "android" &&
-				attr.Namespace == "http://schemas.android.com/apk/res/android" {
-				listener.tempActivityName = attr.Value
-				break
-			}
-		}
-	}
-
-	if localName != "action" {
-		return
-	}
-
-	//fmt.Println(uri, localName, qName)
-	for _, attr := range attrs {
-		if attr.Name == "name" && attr.Prefix == "android" &&
-			attr.Namespace == "http://schemas.android.com/apk/res/android" &&
-			attr.Value == "android.intent.action.MAIN" {
-			listener.ActivityName = listener.tempActivityName
-			listener.findMainActivity = true
-			break
-		}
 	}
 }
 
@@ -122,7 +130,9 @@ func (listener *AppNameListener) StartElement(uri, localName, qName string,
  *            the qualified XML name (with prefix), or the empty string if
  *            qualified names are not available
  */
-func (listener *AppNameListener) EndElement(uri, localName, qName string) {}
+func (listener *AppNameListener) EndElement(uri, localName, qName string) {
+	listener.tree.EndElement(uri, localName, qName)
+}
 
 /**
  * Receive notification of text.
@@ -138,7 +148,9 @@ func (listener *AppNameListener) Text(data string) {}
  * @param data
  *            the text data
  */
-func (listener *AppNameListener) CharacterData(data string) {}
+func (listener *AppNameListener) CharacterData(data string) {
+	listener.tree.CharacterData(data)
+}
 
 /**
  * Receive notification of a processing instruction.
@@ -0,0 +1,355 @@
+package axmlParser
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Attribute formats, as in the format of an <attr> declaration
+const (
+	FORMAT_REFERENCE = 1 << 0
+	FORMAT_STRING    = 1 << 1
+	FORMAT_INTEGER   = 1 << 2
+	FORMAT_BOOLEAN   = 1 << 3
+	FORMAT_COLOR     = 1 << 4
+	FORMAT_FLOAT     = 1 << 5
+	FORMAT_DIMENSION = 1 << 6
+	FORMAT_FRACTION  = 1 << 7
+	FORMAT_ANY       = 0xFFFF
+	FORMAT_ENUM      = 1 << 16
+	FORMAT_FLAGS     = 1 << 17
+)
+
+// attrFormat is the format of a framework attribute, with the names of its
+// enum or flag values.
+type attrFormat struct {
+	format int
+	values map[string]int
+}
+
+var (
+	stringFormat    = attrFormat{format: FORMAT_REFERENCE | FORMAT_STRING}
+	referenceFormat = attrFormat{format: FORMAT_REFERENCE}
+	intFormat       = attrFormat{format: FORMAT_INTEGER}
+	boolFormat      = attrFormat{format: FORMAT_REFERENCE | FORMAT_BOOLEAN}
+	// minSdkVersion and targetSdkVersion also take codenames
+	sdkFormat = attrFormat{format: FORMAT_INTEGER | FORMAT_STRING}
+)
+
+func enumFormat(values map[string]int) attrFormat {
+	return attrFormat{format: FORMAT_ENUM, values: values}
+}
+
+func flagsFormat(values map[string]int) attrFormat {
+	return attrFormat{format: FORMAT_FLAGS, values: values}
+}
+
+// androidAttrFormats holds the formats of the framework attributes used in
+// manifests. Other attributes take any format.
+var androidAttrFormats = map[string]attrFormat{
+	"name":                      stringFormat,
+	"label":                     stringFormat,
+	"description":               stringFormat,
+	"versionName":               stringFormat,
+	"process":                   stringFormat,
+	"authorities":               stringFormat,
+	"permission":                stringFormat,
+	"readPermission":            stringFormat,
+	"writePermission":           stringFormat,
+	"permissionGroup":           stringFormat,
+	"targetActivity":            stringFormat,
+	"targetPackage":             stringFormat,
+	"taskAffinity":              stringFormat,
+	"sharedUserId":              stringFormat,
+	"sharedUserLabel":           referenceFormat,
+	"scheme":                    stringFormat,
+	"host":                      stringFormat,
+	"port":                      stringFormat,
+	"path":                      stringFormat,
+	"pathPrefix":                stringFormat,
+	"pathPattern":               stringFormat,
+	"mimeType":                  stringFormat,
+	"backupAgent":               stringFormat,
+	"manageSpaceActivity":       stringFormat,
+	"parentActivityName":        stringFormat,
+	"appComponentFactory":       stringFormat,
+	"requiredFeature":           stringFormat,
+	"requiredNotFeature":        stringFormat,
+	"compileSdkVersionCodename": stringFormat,
+	"icon":                      referenceFormat,
+	"roundIcon":                 referenceFormat,
+	"logo":                      referenceFormat,
+	"banner":                    referenceFormat,
+	"theme":                     referenceFormat,
+	"resource":                  referenceFormat,
+	"networkSecurityConfig":     referenceFormat,
+	"fullBackupContent":         boolFormat,
+
+	"versionCode":       intFormat,
+	"versionCodeMajor":  intFormat,
+	"minSdkVersion":     sdkFormat,
+	"targetSdkVersion":  sdkFormat,
+	"maxSdkVersion":     intFormat,
+	"compileSdkVersion": intFormat,
+	"priority":          intFormat,
+	"initOrder":         intFormat,
+	"glEsVersion":       intFormat,
+
+	"requiresSmallestWidthDp": intFormat,
+	"compatibleWidthLimitDp":  intFormat,
+	"largestWidthLimitDp":     intFormat,
+
+	"enabled":                      boolFormat,
+	"exported":                     boolFormat,
+	"debuggable":                   boolFormat,
+	"allowBackup":                  boolFormat,
+	"required":                     boolFormat,
+	"hasCode":                      boolFormat,
+	"persistent":                   boolFormat,
+	"allowClearUserData":           boolFormat,
+	"multiprocess":                 boolFormat,
+	"finishOnTaskLaunch":           boolFormat,
+	"clearTaskOnLaunch":            boolFormat,
+	"stateNotNeeded":               boolFormat,
+	"excludeFromRecents":           boolFormat,
+	"syncable":                     boolFormat,
+	"grantUriPermissions":          boolFormat,
+	"handleProfiling":              boolFormat,
+	"functionalTest":               boolFormat,
+	"alwaysRetainTaskState":        boolFormat,
+	"allowTaskReparenting":         boolFormat,
+	"noHistory":                    boolFormat,
+	"reqHardKeyboard":              boolFormat,
+	"reqFiveWayNav":                boolFormat,
+	"anyDensity":                   boolFormat,
+	"testOnly":                     boolFormat,
+	"smallScreens":                 boolFormat,
+	"normalScreens":                boolFormat,
+	"largeScreens":                 boolFormat,
+	"xlargeScreens":                boolFormat,
+	"vmSafeMode":                   boolFormat,
+	"immersive":                    boolFormat,
+	"hardwareAccelerated":          boolFormat,
+	"largeHeap":                    boolFormat,
+	"isolatedProcess":              boolFormat,
+	"supportsRtl":                  boolFormat,
+	"isGame":                       boolFormat,
+	"extractNativeLibs":            boolFormat,
+	"usesCleartextTraffic":         boolFormat,
+	"autoVerify":                   boolFormat,
+	"resizeableActivity":           boolFormat,
+	"supportsPictureInPicture":     boolFormat,
+	"directBootAware":              boolFormat,
+	"requestLegacyExternalStorage": boolFormat,
+
+	"screenSize": enumFormat(map[string]int{
+		"small": 200, "normal": 300, "large": 400, "xlarge": 500,
+	}),
+	"screenDensity": {FORMAT_INTEGER | FORMAT_ENUM, map[string]int{
+		"ldpi": 120, "mdpi": 160, "hdpi": 240, "xhdpi": 320, "xxhdpi": 480, "xxxhdpi": 640,
+	}},
+
+	"launchMode": enumFormat(map[string]int{
+		"standard": 0, "singleTop": 1, "singleTask": 2, "singleInstance": 3, "singleInstancePerTask": 4,
+	}),
+	"screenOrientation": enumFormat(map[string]int{
+		"unspecified": -1, "landscape": 0, "portrait": 1, "user": 2, "behind": 3, "sensor": 4,
+		"nosensor": 5, "sensorLandscape": 6, "sensorPortrait": 7, "reverseLandscape": 8,
+		"reversePortrait": 9, "fullSensor": 10, "userLandscape": 11, "userPortrait": 12,
+		"fullUser": 13, "locked": 14,
+	}),
+	"installLocation": enumFormat(map[string]int{
+		"auto": 0, "internalOnly": 1, "preferExternal": 2,
+	}),
+	"uiOptions": enumFormat(map[string]int{
+		"none": 0, "splitActionBarWhenNarrow": 1,
+	}),
+	"reqTouchScreen": enumFormat(map[string]int{
+		"undefined": 0, "notouch": 1, "stylus": 2, "finger": 3,
+	}),
+	"reqKeyboardType": enumFormat(map[string]int{
+		"undefined": 0, "nokeys": 1, "qwerty": 2, "twelvekey": 3,
+	}),
+	"reqNavigation": enumFormat(map[string]int{
+		"undefined": 0, "nonav": 1, "dpad": 2, "trackball": 3, "wheel": 4,
+	}),
+	"protectionLevel": flagsFormat(map[string]int{
+		"normal": 0, "dangerous": 1, "signature": 2, "signatureOrSystem": 3,
+		"privileged": 0x10, "system": 0x10, "development": 0x20, "appop": 0x40,
+		"pre23": 0x80, "installer": 0x100, "verifier": 0x200, "preinstalled": 0x400,
+		"setup": 0x800, "instant": 0x1000, "runtime": 0x2000,
+	}),
+	"configChanges": flagsFormat(map[string]int{
+		"mcc": 0x1, "mnc": 0x2, "locale": 0x4, "touchscreen": 0x8, "keyboard": 0x10,
+		"keyboardHidden": 0x20, "navigation": 0x40, "orientation": 0x80, "screenLayout": 0x100,
+		"uiMode": 0x200, "screenSize": 0x400, "smallestScreenSize": 0x800, "density": 0x1000,
+		"layoutDirection": 0x2000, "colorMode": 0x4000, "fontWeightAdjustment": 0x10000000,
+		"fontScale": 0x40000000,
+	}),
+	"windowSoftInputMode": flagsFormat(map[string]int{
+		"stateUnspecified": 0, "stateUnchanged": 1, "stateHidden": 2, "stateAlwaysHidden": 3,
+		"stateVisible": 4, "stateAlwaysVisible": 5, "adjustUnspecified": 0x00,
+		"adjustResize": 0x10, "adjustPan": 0x20, "adjustNothing": 0x30,
+	}),
+	"foregroundServiceType": flagsFormat(map[string]int{
+		"dataSync": 0x1, "mediaPlayback": 0x2, "phoneCall": 0x4, "location": 0x8,
+		"connectedDevice": 0x10, "mediaProjection": 0x20, "camera": 0x40, "microphone": 0x80,
+		"health": 0x100, "remoteMessaging": 0x200, "systemExempted": 0x400,
+		"shortService": 0x800, "specialUse": 0x40000000,
+	}),
+}
+
+// typedAttrValue types the text value of an attribute the way aapt2 does,
+// from the format of the framework attribute android:name. Attributes out
+// of the android namespace stay strings.
+func typedAttrValue(ns, name, value string) (tpe int, data int, err error) {
+	format := attrFormat{format: FORMAT_STRING}
+	if ns == ANDROID_NAMESPACE {
+		var ok bool
+		if format, ok = androidAttrFormats[name]; !ok {
+			format = attrFormat{format: FORMAT_ANY}
+		}
+	}
+
+	if tpe, data, ok := parseTypedValue(value, format); ok {
+		return tpe, data, nil
+	}
+	return 0, 0, fmt.Errorf("axmlParser: invalid value %q for attribute %s", value, name)
+}
+
+// parseTypedValue parses value as the first of the allowed formats it
+// matches. String values are returned with RES_TYPE_STRING and no data.
+func parseTypedValue(value string, format attrFormat) (int, int, bool) {
+	s := strings.TrimSpace(value)
+	switch {
+	case s == "@null":
+		return RES_TYPE_REFERENCE, 0, true
+	case s == "@empty":
+		return RES_TYPE_NULL, DATA_NULL_EMPTY, true
+	case strings.HasPrefix(s, "@"), strings.HasPrefix(s, "?"):
+		// only references by ID can be encoded without a resource table
+		id, err := strconv.ParseUint(strings.TrimPrefix(s[1:], "0x"), 16, 32)
+		if err != nil || !strings.HasPrefix(s[1:], "0x") {
+			return 0, 0, false
+		}
+		if s[0] == '?' {
+			return RES_TYPE_ATTRIBUTE, int(id), true
+		}
+		return RES_TYPE_REFERENCE, int(id), true
+	}
+
+	if format.format&FORMAT_ENUM != 0 {
+		if v, ok := format.values[s]; ok {
+			return RES_TYPE_INT_DEC, int(uint32(int32(v))), true
+		}
+	}
+	if format.format&FORMAT_FLAGS != 0 {
+		flags, ok := 0, s != ""
+		for _, flag := range strings.Split(s, "|") {
+			v, found := format.values[strings.TrimSpace(flag)]
+			ok = ok && found
+			flags |= v
+		}
+		if ok {
+			return RES_TYPE_INT_HEX, flags, true
+		}
+	}
+	if format.format&FORMAT_COLOR != 0 {
+		if tpe, data, ok := parseColor(s); ok {
+			return tpe, data, true
+		}
+	}
+	if format.format&FORMAT_BOOLEAN != 0 {
+		switch s {
+		case "true":
+			return RES_TYPE_INT_BOOLEAN, 0xFFFFFFFF, true
+		case "false":
+			return RES_TYPE_INT_BOOLEAN, 0, true
+		}
+	}
+	if format.format&FORMAT_INTEGER != 0 {
+		if strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X") {
+			if v, err := strconv.ParseUint(s[2:], 16, 32); err == nil {
+				return RES_TYPE_INT_HEX, int(v), true
+			}
+		} else if v, err := strconv.ParseInt(s, 10, 32); err == nil {
+			return RES_TYPE_INT_DEC, int(uint32(v)), true
+		}
+	}
+	if format.format&FORMAT_FLOAT != 0 {
+		if f, err := strconv.ParseFloat(s, 32); err == nil && !math.IsInf(f, 0) && !math.IsNaN(f) {
+			return RES_TYPE_FLOAT, int(math.Float32bits(float32(f))), true
+		}
+	}
+	if format.format&FORMAT_DIMENSION != 0 {
+		if data, ok := parseComplex(s, DIMEN, 1); ok {
+			return RES_TYPE_DIMENSION, data, true
+		}
+	}
+	if format.format&FORMAT_FRACTION != 0 {
+		if data, ok := parseComplex(s, FRACTION, 100); ok {
+			return RES_TYPE_FRACTION, data, true
+		}
+	}
+	if format.format&FORMAT_STRING != 0 {
+		return RES_TYPE_STRING, 0, true
+	}
+	return 0, 0, false
+}
+
+// parseColor parses #rgb, #argb, #rrggbb and #aarrggbb colors.
+func parseColor(s string) (int, int, bool) {
+	if !strings.HasPrefix(s, "#") {
+		return 0, 0, false
+	}
+	v, err := strconv.ParseUint(s[1:], 16, 32)
+	if err != nil {
+		return 0, 0, false
+	}
+	// 4 bit channels are stored doubled, with an opaque alpha if missing
+	expand := func(v uint64) uint64 {
+		var out uint64
+		for i := uint(0); i < 4; i++ {
+			nibble := v >> (4 * i) & 0xF
+			out |= (nibble<<4 | nibble) << (8 * i)
+		}
+		return out
+	}
+	switch len(s) - 1 {
+	case 3:
+		return RES_TYPE_INT_COLOR_RGB4, int(0xFF000000 | expand(v)), true
+	case 4:
+		return RES_TYPE_INT_COLOR_ARGB4, int(expand(v)), true
+	case 6:
+		return RES_TYPE_INT_COLOR_RGB8, int(0xFF000000 | v), true
+	case 8:
+		return RES_TYPE_INT_COLOR_ARGB8, int(v), true
+	}
+	return 0, 0, false
+}
+
+// parseComplex parses a number followed by one of units, such as 16dp or
+// 50%p, into a complex data word. The number is divided by scale.
+func parseComplex(s string, units []string, scale float32) (int, bool) {
+	// try longer units first, so that %p is not read as %
+	best := -1
+	for i, unit := range units {
+		if strings.HasSuffix(s, unit) && (best < 0 || len(unit) > len(units[best])) {
+			best = i
+		}
+	}
+	if best < 0 {
+		return 0, false
+	}
+	f, err := strconv.ParseFloat(s[:len(s)-len(units[best])], 32)
+	if err != nil {
+		return 0, false
+	}
+	data, ok := floatToComplex(float32(f) / scale)
+	if !ok {
+		return 0, false
+	}
+	return data | best<<COMPLEX_UNIT_SHIFT, true
+}
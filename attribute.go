@@ -1,5 +1,102 @@
 package axmlParser
 
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Res_value data types, as found in the dataType byte of a typed value
+const (
+	RES_TYPE_NULL              = 0x00
+	RES_TYPE_REFERENCE         = 0x01
+	RES_TYPE_ATTRIBUTE         = 0x02
+	RES_TYPE_STRING            = 0x03
+	RES_TYPE_FLOAT             = 0x04
+	RES_TYPE_DIMENSION         = 0x05
+	RES_TYPE_FRACTION          = 0x06
+	RES_TYPE_DYNAMIC_REFERENCE = 0x07
+	RES_TYPE_DYNAMIC_ATTRIBUTE = 0x08
+	RES_TYPE_INT_DEC           = 0x10
+	RES_TYPE_INT_HEX           = 0x11
+	RES_TYPE_INT_BOOLEAN       = 0x12
+	RES_TYPE_INT_COLOR_ARGB8   = 0x1c
+	RES_TYPE_INT_COLOR_RGB8    = 0x1d
+	RES_TYPE_INT_COLOR_ARGB4   = 0x1e
+	RES_TYPE_INT_COLOR_RGB4    = 0x1f
+
+	RES_TYPE_FIRST_INT = RES_TYPE_INT_DEC
+	RES_TYPE_LAST_INT  = RES_TYPE_INT_COLOR_RGB4
+
+	RES_TYPE_FIRST_COLOR_INT = RES_TYPE_INT_COLOR_ARGB8
+	RES_TYPE_LAST_COLOR_INT  = RES_TYPE_INT_COLOR_RGB4
+
+	// data of a RES_TYPE_NULL value
+	DATA_NULL_UNDEFINED = 0
+	DATA_NULL_EMPTY     = 1
+)
+
+// ErrValueType is returned by the typed accessors of Attribute when the
+// value is not of the requested type.
+var ErrValueType = errors.New("axmlParser: attribute value has another type")
+
 type Attribute struct {
 	Name, Prefix, Namespace, Value string
+
+	// Type is the Res_value data type of the value, one of RES_TYPE_*
+	Type int
+	// Data is the raw Res_value data word
+	Data int
+	// StringIndex is the index of the raw value in the string pool, or -1
+	StringIndex int
+	// NameResourceID is the resource ID mapped to the attribute name by the
+	// XML resource map, or 0 if it has none
+	NameResourceID int
+	// RawName is the name as found in the string pool. Name holds the
+	// framework name instead when NameResourceID is a known android.R.attr
+	RawName string
+}
+
+func (attr *Attribute) typeError(want string) error {
+	return fmt.Errorf("%w: %s is type 0x%02x, not %s", ErrValueType, attr.Name, attr.Type, want)
+}
+
+// Int returns the value of an integer, boolean or color attribute.
+func (attr *Attribute) Int() (int, error) {
+	if attr.Type < RES_TYPE_FIRST_INT || attr.Type > RES_TYPE_LAST_INT {
+		return 0, attr.typeError("an integer")
+	}
+	return int(int32(attr.Data)), nil
+}
+
+// Bool returns the value of a boolean attribute.
+func (attr *Attribute) Bool() (bool, error) {
+	if attr.Type != RES_TYPE_INT_BOOLEAN {
+		return false, attr.typeError("a boolean")
+	}
+	return attr.Data != 0, nil
+}
+
+// Float returns the value of a float attribute.
+func (attr *Attribute) Float() (float32, error) {
+	if attr.Type != RES_TYPE_FLOAT {
+		return 0, attr.typeError("a float")
+	}
+	return math.Float32frombits(uint32(attr.Data)), nil
+}
+
+// IsReference reports whether the value refers to a resource (@...).
+func (attr *Attribute) IsReference() bool {
+	return attr.Type == RES_TYPE_REFERENCE || attr.Type == RES_TYPE_DYNAMIC_REFERENCE
+}
+
+// ResourceID returns the resource ID a reference (@...) or theme
+// attribute (?...) value points to.
+func (attr *Attribute) ResourceID() (int, error) {
+	switch attr.Type {
+	case RES_TYPE_REFERENCE, RES_TYPE_DYNAMIC_REFERENCE,
+		RES_TYPE_ATTRIBUTE, RES_TYPE_DYNAMIC_ATTRIBUTE:
+		return attr.Data, nil
+	}
+	return 0, attr.typeError("a reference")
 }
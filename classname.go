@@ -0,0 +1,65 @@
+package axmlParser
+
+import "strings"
+
+// ClassNames lists the fully qualified class names of the components
+// declared in a manifest, in manifest order.
+type ClassNames struct {
+	Application     string
+	Activities      []string
+	ActivityAliases []string
+	Services        []string
+	Receivers       []string
+	Providers       []string
+}
+
+// ResolveClassName expands a component name relative to pkg, the way the
+// package manager does: ".Main" and "Main" both become pkg + ".Main", and
+// names with a dot past the first character are already qualified.
+func ResolveClassName(pkg, name string) string {
+	switch {
+	case name == "":
+		return ""
+	case strings.HasPrefix(name, "."):
+		return pkg + name
+	case !strings.Contains(name, "."):
+		return pkg + "." + name
+	}
+	return name
+}
+
+// ClassName resolves a component name of the manifest against its
+// namespace, or its package when no namespace is set.
+func (manifest *AndroidManifest) ClassName(name string) string {
+	return ResolveClassName(firstNonEmpty(manifest.Namespace, manifest.Package), name)
+}
+
+// ClassNames resolves the class names of the application and of all its
+// components.
+func (manifest *AndroidManifest) ClassNames() *ClassNames {
+	app := &manifest.Application
+	names := &ClassNames{
+		Application:     manifest.ClassName(app.Name),
+		Activities:      make([]string, len(app.Activities)),
+		ActivityAliases: make([]string, len(app.ActivityAliases)),
+		Services:        make([]string, len(app.Services)),
+		Receivers:       make([]string, len(app.Receivers)),
+		Providers:       make([]string, len(app.Providers)),
+	}
+	for i, c := range app.Activities {
+		names.Activities[i] = manifest.ClassName(c.Name)
+	}
+	for i, c := range app.ActivityAliases {
+		names.ActivityAliases[i] = manifest.ClassName(c.Name)
+	}
+	for i, c := range app.Services {
+		names.Services[i] = manifest.ClassName(c.Name)
+	}
+	for i, c := range app.Receivers {
+		names.Receivers[i] = manifest.ClassName(c.Name)
+	}
+	for i, c := range app.Providers {
+		names.Providers[i] = manifest.ClassName(c.Name)
+	}
+	return names
+}
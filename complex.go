@@ -0,0 +1,99 @@
+package axmlParser
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Layout of the complex data word used by dimension and fraction values
+const (
+	COMPLEX_UNIT_SHIFT     = 0
+	COMPLEX_UNIT_MASK      = 0xF
+	COMPLEX_RADIX_SHIFT    = 4
+	COMPLEX_RADIX_MASK     = 0x3
+	COMPLEX_MANTISSA_SHIFT = 8
+	COMPLEX_MANTISSA_MASK  = 0xFFFFFF
+
+	COMPLEX_UNIT_FRACTION        = 0
+	COMPLEX_UNIT_FRACTION_PARENT = 1
+)
+
+var (
+	// FRACTION holds the suffixes of fraction units, relative to the
+	// element itself or to its parent
+	FRACTION = []string{"%", "%p"}
+
+	// radixMults scales the mantissa for each radix: 23p0, 16p7, 8p15
+	// and 0p23
+	radixMults = []float32{
+		1.0 / (1 << COMPLEX_MANTISSA_SHIFT),
+		1.0 / (1 << 7) / (1 << COMPLEX_MANTISSA_SHIFT),
+		1.0 / (1 << 15) / (1 << COMPLEX_MANTISSA_SHIFT),
+		1.0 / (1 << 23) / (1 << COMPLEX_MANTISSA_SHIFT),
+	}
+)
+
+// ComplexToFloat decodes the value of a complex data word, following the
+// rules of android.util.TypedValue.complexToFloat.
+func ComplexToFloat(complex int) float32 {
+	mantissa := int32(uint32(complex) & (COMPLEX_MANTISSA_MASK << COMPLEX_MANTISSA_SHIFT))
+	return float32(mantissa) * radixMults[(complex>>COMPLEX_RADIX_SHIFT)&COMPLEX_RADIX_MASK]
+}
+
+// formatFloat formats a float32 with as few digits as needed.
+func formatFloat(f float32) string {
+	return strconv.FormatFloat(float64(f), 'g', -1, 32)
+}
+
+// formatDimension formats a dimension value, such as 1.5dp.
+func formatDimension(complex int) string {
+	value := formatFloat(ComplexToFloat(complex))
+	unit := (complex >> COMPLEX_UNIT_SHIFT) & COMPLEX_UNIT_MASK
+	if unit < len(DIMEN) {
+		return value + DIMEN[unit]
+	}
+	return fmt.Sprintf("%s(unit 0x%x)", value, unit)
+}
+
+// formatFraction formats a fraction value, such as 50% or 25%p.
+func formatFraction(complex int) string {
+	value := formatFloat(ComplexToFloat(complex) * 100)
+	unit := (complex >> COMPLEX_UNIT_SHIFT) & COMPLEX_UNIT_MASK
+	if unit < len(FRACTION) {
+		return value + FRACTION[unit]
+	}
+	return fmt.Sprintf("%s(unit 0x%x)", value, unit)
+}
+
+// floatToComplex encodes f as the value of a complex data word, with the
+// most precise radix that holds it, following aapt's floatToComplex.
+func floatToComplex(f float32) (int, bool) {
+	neg := f < 0
+	if neg {
+		f = -f
+	}
+	bits := uint64(float64(f)*(1<<23) + 0.5)
+
+	var radix, shift uint
+	switch {
+	case bits&0x7fffff == 0:
+		radix, shift = 0, 23 // 23p0
+	case bits&0xffffffffff800000 == 0:
+		radix, shift = 3, 0 // 0p23
+	case bits&0xffffffff80000000 == 0:
+		radix, shift = 2, 8 // 8p15
+	case bits&0xffffff8000000000 == 0:
+		radix, shift = 1, 16 // 16p7
+	default:
+		radix, shift = 0, 23
+	}
+	if bits>>shift > 0x7fffff {
+		return 0, false
+	}
+
+	mantissa := int64(bits >> shift)
+	if neg {
+		mantissa = -mantissa & COMPLEX_MANTISSA_MASK
+	}
+	return int(mantissa<<COMPLEX_MANTISSA_SHIFT | int64(radix)<<COMPLEX_RADIX_SHIFT), true
+}
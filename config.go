@@ -0,0 +1,551 @@
+package axmlParser
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ResTable_config values
+const (
+	ORIENTATION_PORT   = 1
+	ORIENTATION_LAND   = 2
+	ORIENTATION_SQUARE = 3
+
+	TOUCHSCREEN_NOTOUCH = 1
+	TOUCHSCREEN_STYLUS  = 2
+	TOUCHSCREEN_FINGER  = 3
+
+	DENSITY_DEFAULT = 0
+	DENSITY_LOW     = 120
+	DENSITY_MEDIUM  = 160
+	DENSITY_TV      = 213
+	DENSITY_HIGH    = 240
+	DENSITY_XHIGH   = 320
+	DENSITY_XXHIGH  = 480
+	DENSITY_XXXHIGH = 640
+	DENSITY_ANY     = 0xFFFE
+	DENSITY_NONE    = 0xFFFF
+
+	KEYBOARD_NOKEYS = 1
+	KEYBOARD_QWERTY = 2
+	KEYBOARD_12KEY  = 3
+
+	NAVIGATION_NONAV     = 1
+	NAVIGATION_DPAD      = 2
+	NAVIGATION_TRACKBALL = 3
+	NAVIGATION_WHEEL     = 4
+
+	MASK_KEYSHIDDEN = 0x03
+	KEYSHIDDEN_NO   = 0x01
+	KEYSHIDDEN_YES  = 0x02
+	KEYSHIDDEN_SOFT = 0x03
+
+	MASK_NAVHIDDEN = 0x0C
+	NAVHIDDEN_NO   = 0x04
+	NAVHIDDEN_YES  = 0x08
+
+	MASK_SCREENSIZE   = 0x0F
+	SCREENSIZE_SMALL  = 0x01
+	SCREENSIZE_NORMAL = 0x02
+	SCREENSIZE_LARGE  = 0x03
+	SCREENSIZE_XLARGE = 0x04
+
+	MASK_SCREENLONG = 0x30
+	SCREENLONG_NO   = 0x10
+	SCREENLONG_YES  = 0x20
+
+	MASK_LAYOUTDIR = 0xC0
+	LAYOUTDIR_LTR  = 0x40
+	LAYOUTDIR_RTL  = 0x80
+
+	MASK_UI_MODE_TYPE       = 0x0F
+	UI_MODE_TYPE_NORMAL     = 0x01
+	UI_MODE_TYPE_DESK       = 0x02
+	UI_MODE_TYPE_CAR        = 0x03
+	UI_MODE_TYPE_TELEVISION = 0x04
+	UI_MODE_TYPE_APPLIANCE  = 0x05
+	UI_MODE_TYPE_WATCH      = 0x06
+	UI_MODE_TYPE_VR_HEADSET = 0x07
+
+	MASK_UI_MODE_NIGHT = 0x30
+	UI_MODE_NIGHT_NO   = 0x10
+	UI_MODE_NIGHT_YES  = 0x20
+
+	MASK_SCREENROUND = 0x03
+	SCREENROUND_NO   = 0x01
+	SCREENROUND_YES  = 0x02
+
+	MASK_WIDE_COLOR_GAMUT = 0x03
+	WIDE_COLOR_GAMUT_NO   = 0x01
+	WIDE_COLOR_GAMUT_YES  = 0x02
+
+	MASK_HDR = 0x0C
+	HDR_NO   = 0x04
+	HDR_YES  = 0x08
+)
+
+// configQualifier maps a qualifier name to the config field and mask it
+// sets.
+type configQualifier struct {
+	name  string
+	field func(*ResourceConfig) *int
+	mask  int
+	value int
+}
+
+func screenLayout(c *ResourceConfig) *int  { return &c.ScreenLayout }
+func screenLayout2(c *ResourceConfig) *int { return &c.ScreenLayout2 }
+func colorMode(c *ResourceConfig) *int     { return &c.ColorMode }
+func orientation(c *ResourceConfig) *int   { return &c.Orientation }
+func uiMode(c *ResourceConfig) *int        { return &c.UiMode }
+func density(c *ResourceConfig) *int       { return &c.Density }
+func touchscreen(c *ResourceConfig) *int   { return &c.Touchscreen }
+func inputFlags(c *ResourceConfig) *int    { return &c.InputFlags }
+func keyboard(c *ResourceConfig) *int      { return &c.Keyboard }
+func navigation(c *ResourceConfig) *int    { return &c.Navigation }
+
+// configQualifiers are the named qualifiers, in the order aapt writes them.
+var configQualifiers = []configQualifier{
+	{"ldltr", screenLayout, MASK_LAYOUTDIR, LAYOUTDIR_LTR},
+	{"ldrtl", screenLayout, MASK_LAYOUTDIR, LAYOUTDIR_RTL},
+	{"small", screenLayout, MASK_SCREENSIZE, SCREENSIZE_SMALL},
+	{"normal", screenLayout, MASK_SCREENSIZE, SCREENSIZE_NORMAL},
+	{"large", screenLayout, MASK_SCREENSIZE, SCREENSIZE_LARGE},
+	{"xlarge", screenLayout, MASK_SCREENSIZE, SCREENSIZE_XLARGE},
+	{"long", screenLayout, MASK_SCREENLONG, SCREENLONG_YES},
+	{"notlong", screenLayout, MASK_SCREENLONG, SCREENLONG_NO},
+	{"round", screenLayout2, MASK_SCREENROUND, SCREENROUND_YES},
+	{"notround", screenLayout2, MASK_SCREENROUND, SCREENROUND_NO},
+	{"widecg", colorMode, MASK_WIDE_COLOR_GAMUT, WIDE_COLOR_GAMUT_YES},
+	{"nowidecg", colorMode, MASK_WIDE_COLOR_GAMUT, WIDE_COLOR_GAMUT_NO},
+	{"highdr", colorMode, MASK_HDR, HDR_YES},
+	{"lowdr", colorMode, MASK_HDR, HDR_NO},
+	{"port", orientation, 0xFF, ORIENTATION_PORT},
+	{"land", orientation, 0xFF, ORIENTATION_LAND},
+	{"square", orientation, 0xFF, ORIENTATION_SQUARE},
+	{"desk", uiMode, MASK_UI_MODE_TYPE, UI_MODE_TYPE_DESK},
+	{"car", uiMode, MASK_UI_MODE_TYPE, UI_MODE_TYPE_CAR},
+	{"television", uiMode, MASK_UI_MODE_TYPE, UI_MODE_TYPE_TELEVISION},
+	{"appliance", uiMode, MASK_UI_MODE_TYPE, UI_MODE_TYPE_APPLIANCE},
+	{"watch", uiMode, MASK_UI_MODE_TYPE, UI_MODE_TYPE_WATCH},
+	{"vrheadset", uiMode, MASK_UI_MODE_TYPE, UI_MODE_TYPE_VR_HEADSET},
+	{"night", uiMode, MASK_UI_MODE_NIGHT, UI_MODE_NIGHT_YES},
+	{"notnight", uiMode, MASK_UI_MODE_NIGHT, UI_MODE_NIGHT_NO},
+	{"ldpi", density, 0xFFFF, DENSITY_LOW},
+	{"mdpi", density, 0xFFFF, DENSITY_MEDIUM},
+	{"tvdpi", density, 0xFFFF, DENSITY_TV},
+	{"hdpi", density, 0xFFFF, DENSITY_HIGH},
+	{"xhdpi", density, 0xFFFF, DENSITY_XHIGH},
+	{"xxhdpi", density, 0xFFFF, DENSITY_XXHIGH},
+	{"xxxhdpi", density, 0xFFFF, DENSITY_XXXHIGH},
+	{"anydpi", density, 0xFFFF, DENSITY_ANY},
+	{"nodpi", density, 0xFFFF, DENSITY_NONE},
+	{"notouch", touchscreen, 0xFF, TOUCHSCREEN_NOTOUCH},
+	{"stylus", touchscreen, 0xFF, TOUCHSCREEN_STYLUS},
+	{"finger", touchscreen, 0xFF, TOUCHSCREEN_FINGER},
+	{"keysexposed", inputFlags, MASK_KEYSHIDDEN, KEYSHIDDEN_NO},
+	{"keyshidden", inputFlags, MASK_KEYSHIDDEN, KEYSHIDDEN_YES},
+	{"keyssoft", inputFlags, MASK_KEYSHIDDEN, KEYSHIDDEN_SOFT},
+	{"nokeys", keyboard, 0xFF, KEYBOARD_NOKEYS},
+	{"qwerty", keyboard, 0xFF, KEYBOARD_QWERTY},
+	{"12key", keyboard, 0xFF, KEYBOARD_12KEY},
+	{"navexposed", inputFlags, MASK_NAVHIDDEN, NAVHIDDEN_NO},
+	{"navhidden", inputFlags, MASK_NAVHIDDEN, NAVHIDDEN_YES},
+	{"nonav", navigation, 0xFF, NAVIGATION_NONAV},
+	{"dpad", navigation, 0xFF, NAVIGATION_DPAD},
+	{"trackball", navigation, 0xFF, NAVIGATION_TRACKBALL},
+	{"wheel", navigation, 0xFF, NAVIGATION_WHEEL},
+}
+
+// ParseConfig parses resource qualifiers, as in the name of a resource
+// directory, such as "zh-rCN-xxhdpi-v26" or "b+sr+Latn-night". An empty
+// string is the default configuration.
+func ParseConfig(qualifiers string) (ResourceConfig, error) {
+	var config ResourceConfig
+	if qualifiers == "" {
+		return config, nil
+	}
+
+	for _, part := range strings.Split(qualifiers, "-") {
+		if !config.parseQualifier(part) {
+			return config, fmt.Errorf("axmlParser: invalid qualifier %q in %q", part, qualifiers)
+		}
+	}
+	return config, nil
+}
+
+func (config *ResourceConfig) parseQualifier(part string) bool {
+	lower := strings.ToLower(part)
+	for _, q := range configQualifiers {
+		if lower == q.name {
+			field := q.field(config)
+			*field = *field&^q.mask | q.value
+			return true
+		}
+	}
+
+	number := func(prefix, suffix string) (int, bool) {
+		if !strings.HasPrefix(lower, prefix) || !strings.HasSuffix(lower, suffix) {
+			return 0, false
+		}
+		n, err := strconv.Atoi(lower[len(prefix) : len(lower)-len(suffix)])
+		return n, err == nil && n >= 0
+	}
+	if n, ok := number("mcc", ""); ok {
+		config.Mcc = n
+		return true
+	}
+	if n, ok := number("mnc", ""); ok {
+		config.Mnc = n
+		return true
+	}
+	if n, ok := number("sw", "dp"); ok {
+		config.SmallestScreenWidthDp = n
+		return true
+	}
+	if n, ok := number("w", "dp"); ok {
+		config.ScreenWidthDp = n
+		return true
+	}
+	if n, ok := number("h", "dp"); ok {
+		config.ScreenHeightDp = n
+		return true
+	}
+	if n, ok := number("", "dpi"); ok {
+		config.Density = n
+		return true
+	}
+	if n, ok := number("v", ""); ok {
+		config.SdkVersion = n
+		return true
+	}
+	if i := strings.Index(lower, "x"); i > 0 {
+		w, err1 := strconv.Atoi(lower[:i])
+		h, err2 := strconv.Atoi(lower[i+1:])
+		if err1 == nil && err2 == nil {
+			config.ScreenWidth, config.ScreenHeight = w, h
+			return true
+		}
+	}
+
+	switch {
+	case strings.HasPrefix(lower, "b+"):
+		// BCP 47 tag: b+language[+script][+region][+variant]
+		subtags := strings.Split(part[2:], "+")
+		if !isLetters(subtags[0], 2, 3) {
+			return false
+		}
+		config.Language = strings.ToLower(subtags[0])
+		for _, subtag := range subtags[1:] {
+			switch {
+			case isLetters(subtag, 4, 4):
+				config.LocaleScript = strings.ToUpper(subtag[:1]) + strings.ToLower(subtag[1:])
+			case isLetters(subtag, 2, 2) || isDigits(subtag, 3):
+				config.Country = strings.ToUpper(subtag)
+			case len(subtag) >= 4 && len(subtag) <= 8:
+				config.LocaleVariant = strings.ToLower(subtag)
+			default:
+				return false
+			}
+		}
+		return true
+	case config.Language == "" && isLetters(lower, 2, 3):
+		config.Language = lower
+		return true
+	case config.Language != "" && config.Country == "" && len(part) == 3 && lower[0] == 'r' &&
+		isLetters(part[1:], 2, 2):
+		config.Country = strings.ToUpper(part[1:])
+		return true
+	}
+	return false
+}
+
+func isLetters(s string, min, max int) bool {
+	if len(s) < min || len(s) > max {
+		return false
+	}
+	for _, c := range s {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z') {
+			return false
+		}
+	}
+	return true
+}
+
+func isDigits(s string, n int) bool {
+	if len(s) != n {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Locale returns the BCP 47 tag of the config locale, such as "zh-CN", or
+// "" if it has none.
+func (config ResourceConfig) Locale() string {
+	tags := make([]string, 0, 4)
+	for _, tag := range []string{config.Language, config.LocaleScript, config.Country,
+		config.LocaleVariant} {
+		if tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return strings.Join(tags, "-")
+}
+
+// String returns the qualifiers of config as aapt writes them, such as
+// "zh-rCN-xxhdpi-v26", or "" for the default configuration.
+func (config ResourceConfig) String() string {
+	parts := make([]string, 0)
+	if config.Mcc != 0 {
+		parts = append(parts, fmt.Sprintf("mcc%d", config.Mcc))
+	}
+	if config.Mnc != 0 {
+		parts = append(parts, fmt.Sprintf("mnc%d", config.Mnc))
+	}
+	switch {
+	case config.LocaleScript != "" || config.LocaleVariant != "" || len(config.Country) == 3:
+		parts = append(parts, "b+"+strings.Replace(config.Locale(), "-", "+", -1))
+	case config.Language != "":
+		parts = append(parts, config.Language)
+		if config.Country != "" {
+			parts = append(parts, "r"+config.Country)
+		}
+	}
+
+	named := func(field func(*ResourceConfig) *int, mask int) {
+		value := *field(&config) & mask
+		for _, q := range configQualifiers {
+			if q.mask == mask && q.value == value && value != 0 &&
+				q.field(&config) == field(&config) {
+				parts = append(parts, q.name)
+				return
+			}
+		}
+	}
+	named(screenLayout, MASK_LAYOUTDIR)
+	if config.SmallestScreenWidthDp != 0 {
+		parts = append(parts, fmt.Sprintf("sw%ddp", config.SmallestScreenWidthDp))
+	}
+	if config.ScreenWidthDp != 0 {
+		parts = append(parts, fmt.Sprintf("w%ddp", config.ScreenWidthDp))
+	}
+	if config.ScreenHeightDp != 0 {
+		parts = append(parts, fmt.Sprintf("h%ddp", config.ScreenHeightDp))
+	}
+	named(screenLayout, MASK_SCREENSIZE)
+	named(screenLayout, MASK_SCREENLONG)
+	named(screenLayout2, MASK_SCREENROUND)
+	named(colorMode, MASK_WIDE_COLOR_GAMUT)
+	named(colorMode, MASK_HDR)
+	named(orientation, 0xFF)
+	named(uiMode, MASK_UI_MODE_TYPE)
+	named(uiMode, MASK_UI_MODE_NIGHT)
+	if config.Density != 0 {
+		before := len(parts)
+		named(density, 0xFFFF)
+		if len(parts) == before {
+			parts = append(parts, fmt.Sprintf("%ddpi", config.Density))
+		}
+	}
+	named(touchscreen, 0xFF)
+	named(inputFlags, MASK_KEYSHIDDEN)
+	named(keyboard, 0xFF)
+	named(inputFlags, MASK_NAVHIDDEN)
+	named(navigation, 0xFF)
+	if config.ScreenWidth != 0 || config.ScreenHeight != 0 {
+		parts = append(parts, fmt.Sprintf("%dx%d", config.ScreenWidth, config.ScreenHeight))
+	}
+	if config.SdkVersion != 0 {
+		parts = append(parts, fmt.Sprintf("v%d", config.SdkVersion))
+	}
+	return strings.Join(parts, "-")
+}
+
+// Match reports whether resources for config can be used on a device with
+// the requested configuration, following ResTable_config::match.
+func (config *ResourceConfig) Match(requested *ResourceConfig) bool {
+	if config.Mcc != 0 && config.Mcc != requested.Mcc ||
+		config.Mnc != 0 && config.Mnc != requested.Mnc {
+		return false
+	}
+	if config.Language != "" && config.Language != requested.Language ||
+		config.Country != "" && config.Country != requested.Country ||
+		config.LocaleScript != "" && config.LocaleScript != requested.LocaleScript ||
+		config.LocaleVariant != "" && config.LocaleVariant != requested.LocaleVariant {
+		return false
+	}
+
+	// masked fields match when unset, or equal
+	masked := []struct{ have, want, mask int }{
+		{config.ScreenLayout, requested.ScreenLayout, MASK_LAYOUTDIR},
+		{config.ScreenLayout, requested.ScreenLayout, MASK_SCREENLONG},
+		{config.ScreenLayout2, requested.ScreenLayout2, MASK_SCREENROUND},
+		{config.ColorMode, requested.ColorMode, MASK_WIDE_COLOR_GAMUT},
+		{config.ColorMode, requested.ColorMode, MASK_HDR},
+		{config.UiMode, requested.UiMode, MASK_UI_MODE_TYPE},
+		{config.UiMode, requested.UiMode, MASK_UI_MODE_NIGHT},
+		{config.Orientation, requested.Orientation, 0xFF},
+		{config.Touchscreen, requested.Touchscreen, 0xFF},
+		{config.Keyboard, requested.Keyboard, 0xFF},
+		{config.InputFlags, requested.InputFlags, MASK_NAVHIDDEN},
+		{config.Navigation, requested.Navigation, 0xFF},
+	}
+	for _, m := range masked {
+		if have := m.have & m.mask; have != 0 && have != m.want&m.mask {
+			return false
+		}
+	}
+	// soft keys match exposed and hidden keyboards
+	if keys := config.InputFlags & MASK_KEYSHIDDEN; keys != 0 {
+		want := requested.InputFlags & MASK_KEYSHIDDEN
+		if keys != want && !(keys == KEYSHIDDEN_NO && want == KEYSHIDDEN_SOFT) {
+			return false
+		}
+	}
+
+	// sizes and versions match when unset, or not above the requested one
+	atMost := []struct{ have, want int }{
+		{config.ScreenLayout & MASK_SCREENSIZE, requested.ScreenLayout & MASK_SCREENSIZE},
+		{config.SmallestScreenWidthDp, requested.SmallestScreenWidthDp},
+		{config.ScreenWidthDp, requested.ScreenWidthDp},
+		{config.ScreenHeightDp, requested.ScreenHeightDp},
+		{config.ScreenWidth, requested.ScreenWidth},
+		{config.ScreenHeight, requested.ScreenHeight},
+		{config.SdkVersion, requested.SdkVersion},
+	}
+	for _, m := range atMost {
+		if m.have != 0 && m.have > m.want {
+			return false
+		}
+	}
+	if config.MinorVersion != 0 && config.MinorVersion != requested.MinorVersion {
+		return false
+	}
+	return true
+}
+
+// IsBetterThan reports whether config is a better match than o for the
+// requested configuration, both matching it, following
+// ResTable_config::isBetterThan.
+func (config *ResourceConfig) IsBetterThan(o, requested *ResourceConfig) bool {
+	set := func(s string) int {
+		if s == "" {
+			return 0
+		}
+		return 1
+	}
+	// a rule is decided by the first field the two configs differ in and
+	// the device has: larger values win for sizes and versions, set values
+	// over unset ones for the rest
+	type rule struct {
+		have, other, want int
+		larger            bool
+	}
+	rules := func(rules ...rule) (bool, bool) {
+		for _, r := range rules {
+			if r.have == r.other || r.want == 0 {
+				continue
+			}
+			if r.larger {
+				return r.have > r.other, true
+			}
+			return r.have != 0, true
+		}
+		return false, false
+	}
+
+	better, decided := rules(
+		rule{config.Mcc, o.Mcc, requested.Mcc, false},
+		rule{config.Mnc, o.Mnc, requested.Mnc, false},
+		rule{set(config.Language), set(o.Language), set(requested.Language), false},
+		rule{set(config.LocaleScript), set(o.LocaleScript), set(requested.LocaleScript), false},
+		rule{set(config.Country), set(o.Country), set(requested.Country), false},
+		rule{set(config.LocaleVariant), set(o.LocaleVariant), set(requested.LocaleVariant), false},
+		rule{config.ScreenLayout & MASK_LAYOUTDIR, o.ScreenLayout & MASK_LAYOUTDIR,
+			requested.ScreenLayout & MASK_LAYOUTDIR, false},
+		rule{config.SmallestScreenWidthDp, o.SmallestScreenWidthDp,
+			requested.SmallestScreenWidthDp, true},
+		rule{config.ScreenWidthDp, o.ScreenWidthDp, requested.ScreenWidthDp, true},
+		rule{config.ScreenHeightDp, o.ScreenHeightDp, requested.ScreenHeightDp, true},
+		rule{config.ScreenLayout & MASK_SCREENSIZE, o.ScreenLayout & MASK_SCREENSIZE,
+			requested.ScreenLayout & MASK_SCREENSIZE, true},
+		rule{config.ScreenLayout & MASK_SCREENLONG, o.ScreenLayout & MASK_SCREENLONG,
+			requested.ScreenLayout & MASK_SCREENLONG, false},
+		rule{config.ScreenLayout2 & MASK_SCREENROUND, o.ScreenLayout2 & MASK_SCREENROUND,
+			requested.ScreenLayout2 & MASK_SCREENROUND, false},
+		rule{config.ColorMode & MASK_HDR, o.ColorMode & MASK_HDR,
+			requested.ColorMode & MASK_HDR, false},
+		rule{config.ColorMode & MASK_WIDE_COLOR_GAMUT, o.ColorMode & MASK_WIDE_COLOR_GAMUT,
+			requested.ColorMode & MASK_WIDE_COLOR_GAMUT, false},
+		rule{config.Orientation, o.Orientation, requested.Orientation, false},
+		rule{config.UiMode & MASK_UI_MODE_TYPE, o.UiMode & MASK_UI_MODE_TYPE,
+			requested.UiMode & MASK_UI_MODE_TYPE, false},
+		rule{config.UiMode & MASK_UI_MODE_NIGHT, o.UiMode & MASK_UI_MODE_NIGHT,
+			requested.UiMode & MASK_UI_MODE_NIGHT, false},
+	)
+	if decided {
+		return better
+	}
+	if better, decided := config.betterDensity(o, requested); decided {
+		return better
+	}
+	better, _ = rules(
+		rule{config.Touchscreen, o.Touchscreen, requested.Touchscreen, false},
+		rule{config.InputFlags & MASK_KEYSHIDDEN, o.InputFlags & MASK_KEYSHIDDEN,
+			requested.InputFlags & MASK_KEYSHIDDEN, false},
+		rule{config.Keyboard, o.Keyboard, requested.Keyboard, false},
+		rule{config.InputFlags & MASK_NAVHIDDEN, o.InputFlags & MASK_NAVHIDDEN,
+			requested.InputFlags & MASK_NAVHIDDEN, false},
+		rule{config.Navigation, o.Navigation, requested.Navigation, false},
+		rule{config.ScreenWidth, o.ScreenWidth, requested.ScreenWidth, true},
+		rule{config.ScreenHeight, o.ScreenHeight, requested.ScreenHeight, true},
+		rule{config.SdkVersion, o.SdkVersion, requested.SdkVersion, true},
+		rule{config.MinorVersion, o.MinorVersion, requested.MinorVersion, false},
+	)
+	return better
+}
+
+// betterDensity prefers the density closest to the requested one, scaling
+// down from a higher density rather than up from a lower one.
+func (config *ResourceConfig) betterDensity(o, requested *ResourceConfig) (bool, bool) {
+	if config.Density == o.Density {
+		return false, false
+	}
+	have, other := config.Density, o.Density
+	if have == DENSITY_DEFAULT {
+		have = DENSITY_MEDIUM
+	}
+	if other == DENSITY_DEFAULT {
+		other = DENSITY_MEDIUM
+	}
+	if have == DENSITY_ANY {
+		return true, true
+	}
+	if other == DENSITY_ANY {
+		return false, true
+	}
+	want := requested.Density
+	if want == DENSITY_DEFAULT || want == DENSITY_ANY {
+		want = DENSITY_MEDIUM
+	}
+
+	high, low, imBigger := have, other, true
+	if low > high {
+		high, low, imBigger = other, have, false
+	}
+	switch {
+	case want >= high:
+		return imBigger, true
+	case low >= want:
+		return !imBigger, true
+	case (2*low-want)*high > want*want:
+		return !imBigger, true
+	}
+	return imBigger, true
+}
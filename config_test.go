@@ -0,0 +1,102 @@
+package axmlParser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseConfig(t *testing.T) {
+	tests := []string{
+		"",
+		"zh-rCN",
+		"fr-xxhdpi-v26",
+		"b+sr+Latn",
+		"mcc310-mnc4-en-rUS-ldrtl-sw600dp-w720dp-large-long-land-television-night-hdpi-finger-keysexposed-qwerty-navhidden-dpad-v21",
+		"round-widecg-highdr-watch-nodpi",
+		"420dpi-1920x1080",
+	}
+	for _, qualifiers := range tests {
+		config, err := ParseConfig(qualifiers)
+		if err != nil {
+			t.Errorf("ParseConfig(%q): %v", qualifiers, err)
+			continue
+		}
+		if got := config.String(); got != qualifiers {
+			t.Errorf("ParseConfig(%q).String() = %q", qualifiers, got)
+		}
+	}
+
+	config, _ := ParseConfig("b+es+419")
+	if config.Locale() != "es-419" {
+		t.Errorf("Locale() = %q, want es-419", config.Locale())
+	}
+	for _, bad := range []string{"zh-CN", "v26x", "hugedpi-"} {
+		if _, err := ParseConfig(bad); err == nil {
+			t.Errorf("ParseConfig(%q) succeeded", bad)
+		}
+	}
+}
+
+func TestBestConfig(t *testing.T) {
+	tests := []struct {
+		requested  string
+		candidates []string
+		want       string
+	}{
+		{"zh-rCN-xxhdpi-v26", []string{"", "zh", "zh-rTW", "zh-rCN"}, "zh-rCN"},
+		{"zh-rHK", []string{"", "zh-rTW", "zh"}, "zh"},
+		{"de", []string{"", "fr"}, ""},
+		{"xxhdpi", []string{"mdpi", "xhdpi", "xxxhdpi"}, "xxxhdpi"},
+		{"hdpi", []string{"mdpi", "xhdpi"}, "xhdpi"},
+		{"xxhdpi", []string{"hdpi", "anydpi"}, "anydpi"},
+		{"v26", []string{"", "v21", "v28"}, "v21"},
+		{"night-v29", []string{"v21", "night"}, "night"},
+		{"en-night", []string{"night", "en"}, "en"},
+		{"sw600dp", []string{"sw320dp", "sw720dp", ""}, "sw320dp"},
+	}
+	for _, test := range tests {
+		requested, err := ParseConfig(test.requested)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var best *ResourceConfig
+		for _, qualifiers := range test.candidates {
+			config, err := ParseConfig(qualifiers)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !config.Match(&requested) {
+				continue
+			}
+			if best == nil || config.IsBetterThan(best, &requested) {
+				best = &config
+			}
+		}
+		if best == nil || best.String() != test.want {
+			t.Errorf("best config for %q in %q = %v, want %q",
+				test.requested, test.candidates, best, test.want)
+		}
+	}
+}
+
+func TestLocalizedStrings(t *testing.T) {
+	table, err := ParseResourceTable(testResourceTable())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := table.LocalizedStrings(0x7f010000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"": "Example", "fr": "Exemple"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("LocalizedStrings = %v, want %v", got, want)
+	}
+
+	// title has no French value of its own, but points to app_name
+	fr, _ := ParseConfig("fr-rFR-xhdpi")
+	if got, err := table.WithConfig(fr).ResolveReference(0x7f010001); err != nil || got != "Exemple" {
+		t.Errorf("ResolveReference(title) in fr = %q, %v", got, err)
+	}
+}
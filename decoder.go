@@ -0,0 +1,77 @@
+package axmlParser
+
+// A Token is one of StartNamespace, EndNamespace, StartElement, EndElement,
+// CharData or RawChunk.
+type Token interface{}
+
+// StartNamespace begins the scope of a prefix-URI namespace mapping.
+type StartNamespace struct {
+	Prefix, URI string
+	Line        int
+}
+
+// EndNamespace ends the scope of a prefix-URI namespace mapping.
+type EndNamespace struct {
+	Prefix, URI string
+	Line        int
+}
+
+// StartElement is the start of an element. QName is Name prefixed with the
+// prefix mapped to Namespace, if any.
+type StartElement struct {
+	Namespace, Name, QName string
+	Attrs                  []*Attribute
+	Line                   int
+}
+
+// EndElement is the end of an element.
+type EndElement struct {
+	Namespace, Name, QName string
+	Line                   int
+}
+
+// CharData is a text node.
+type CharData struct {
+	Data string
+	Line int
+}
+
+// RawChunk is a chunk of the document the decoder does not understand,
+// kept byte for byte, header included.
+type RawChunk struct {
+	Type int
+	Data []byte
+}
+
+// startDocument is returned by nextToken when entering the document chunk.
+type startDocument struct{}
+
+// Decoder reads the tokens of a binary XML document one at a time, as an
+// alternative to receiving Listener callbacks from Parser.Parse.
+type Decoder struct {
+	parser *Parser
+	err    error
+}
+
+func NewDecoder(data []byte) *Decoder {
+	parser := New(nil)
+	parser.Data = data
+	parser.end = len(data)
+	return &Decoder{parser: parser}
+}
+
+// Token returns the next token of the document, or io.EOF once it is
+// exhausted. Once Token returns an error, it keeps returning it.
+func (d *Decoder) Token() (Token, error) {
+	for d.err == nil {
+		var token Token
+		token, d.err = d.parser.nextToken()
+		if d.err != nil {
+			break
+		}
+		if _, ok := token.(startDocument); !ok {
+			return token, nil
+		}
+	}
+	return nil, d.err
+}
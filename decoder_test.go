@@ -0,0 +1,43 @@
+package axmlParser
+
+import (
+	"io"
+	"testing"
+)
+
+func TestDecoderTokens(t *testing.T) {
+	d := NewDecoder(testManifest())
+
+	var tokens []Token
+	for {
+		token, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		tokens = append(tokens, token)
+	}
+
+	if len(tokens) != 4 {
+		t.Fatalf("got %d tokens, want 4: %v", len(tokens), tokens)
+	}
+	if ns, ok := tokens[0].(StartNamespace); !ok || ns.Prefix != "android" {
+		t.Errorf("tokens[0] = %#v, want android StartNamespace", tokens[0])
+	}
+	start, ok := tokens[1].(StartElement)
+	if !ok || start.Name != "manifest" || len(start.Attrs) != 2 || start.Line != 1 {
+		t.Errorf("tokens[1] = %#v, want manifest StartElement", tokens[1])
+	}
+	if end, ok := tokens[2].(EndElement); !ok || end.Name != "manifest" {
+		t.Errorf("tokens[2] = %#v, want manifest EndElement", tokens[2])
+	}
+	if _, ok := tokens[3].(EndNamespace); !ok {
+		t.Errorf("tokens[3] = %#v, want EndNamespace", tokens[3])
+	}
+
+	if _, err := d.Token(); err != io.EOF {
+		t.Errorf("Token() after the end = %v, want io.EOF", err)
+	}
+}
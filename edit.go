@@ -0,0 +1,180 @@
+package axmlParser
+
+import "errors"
+
+var errNoRoot = errors.New("axmlParser: document has no root element")
+
+// componentNameAttrs are the attributes holding class names relative to
+// the manifest package.
+var componentNameAttrs = map[string][]string{
+	"application":    {"name", "backupAgent", "manageSpaceActivity"},
+	"activity":       {"name", "parentActivityName"},
+	"activity-alias": {"name", "targetActivity"},
+	"service":        {"name"},
+	"receiver":       {"name"},
+	"provider":       {"name"},
+}
+
+// NewElement returns an element with no attributes nor children, to be
+// added to a Document with AppendChild or InsertChild.
+func NewElement(ns, name string) *Element {
+	return &Element{Namespace: ns, Name: name, Attrs: make([]*Attribute, 0), Children: make([]Node, 0)}
+}
+
+// SetAttr sets the attribute ns:name to value, typed like ParseXML does,
+// adding the attribute when elem has none. The string pool and resource
+// map grow as needed when the document is encoded. A replaced attribute
+// keeps its resource ID, which only framework attributes get otherwise:
+// adding an attribute of the android namespace with no known resource ID
+// fails with ErrUnknownAttribute.
+func (elem *Element) SetAttr(ns, name, value string) (*Attribute, error) {
+	old := elem.Attr(ns, name)
+	var id int
+	if old != nil {
+		id = old.NameResourceID
+	}
+	attr, err := newAttribute(ns, name, value, id)
+	if err != nil {
+		return nil, err
+	}
+	attr.Prefix, _ = elem.LookupPrefix(ns)
+	if old == nil {
+		elem.Attrs = append(elem.Attrs, attr)
+		return attr, nil
+	}
+	if old.NameResourceID != 0 {
+		attr.RawName = old.RawName
+	}
+	for i, a := range elem.Attrs {
+		if a == old {
+			elem.Attrs[i] = attr
+		}
+	}
+	return attr, nil
+}
+
+// RemoveAttr removes the attribute ns:name, reporting whether elem had it.
+func (elem *Element) RemoveAttr(ns, name string) bool {
+	for i, a := range elem.Attrs {
+		if a.Namespace == ns && a.Name == name {
+			elem.Attrs = append(elem.Attrs[:i], elem.Attrs[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// AppendChild adds child as the last child of elem.
+func (elem *Element) AppendChild(child Node) {
+	elem.InsertChild(len(elem.Children), child)
+}
+
+// InsertChild adds child before the i-th child of elem. An index past the
+// children appends child, and a negative one inserts it first.
+func (elem *Element) InsertChild(i int, child Node) {
+	if i < 0 {
+		i = 0
+	}
+	if i > len(elem.Children) {
+		i = len(elem.Children)
+	}
+	if e, ok := child.(*Element); ok {
+		e.Parent = elem
+	}
+	elem.Children = append(elem.Children, nil)
+	copy(elem.Children[i+1:], elem.Children[i:])
+	elem.Children[i] = child
+}
+
+// RemoveChild removes child from the children of elem, reporting whether
+// it was one.
+func (elem *Element) RemoveChild(child Node) bool {
+	for i, c := range elem.Children {
+		if c == child {
+			elem.Children = append(elem.Children[:i], elem.Children[i+1:]...)
+			if e, ok := child.(*Element); ok {
+				e.Parent = nil
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// RenamePackage sets the package of a manifest document to pkg. Component
+// names relative to the old package are qualified first, so they keep
+// naming the same classes.
+func (doc *Document) RenamePackage(pkg string) error {
+	if doc.Root == nil {
+		return errNoRoot
+	}
+	old := doc.Root.AttrValue("", "package")
+	if old != "" {
+		if err := qualifyClassNames(doc.Root, old); err != nil {
+			return err
+		}
+	}
+	_, err := doc.Root.SetAttr("", "package", pkg)
+	return err
+}
+
+func qualifyClassNames(elem *Element, pkg string) error {
+	for _, name := range componentNameAttrs[elem.Name] {
+		attr := elem.Attr(ANDROID_NAMESPACE, name)
+		if attr == nil || attr.Type != RES_TYPE_STRING {
+			continue
+		}
+		if qualified := ResolveClassName(pkg, attr.Value); qualified != attr.Value {
+			if _, err := elem.SetAttr(ANDROID_NAMESPACE, name, qualified); err != nil {
+				return err
+			}
+		}
+	}
+	for _, child := range elem.Elements() {
+		if err := qualifyClassNames(child, pkg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AddUsesPermission adds a <uses-permission> for name to a manifest
+// document, before <application>, unless it already has one. It returns
+// the element of the permission.
+func (doc *Document) AddUsesPermission(name string) (*Element, error) {
+	if doc.Root == nil {
+		return nil, errNoRoot
+	}
+	for _, elem := range doc.FindAll("uses-permission") {
+		if elem.AttrValue(ANDROID_NAMESPACE, "name") == name {
+			return elem, nil
+		}
+	}
+
+	elem := NewElement("", "uses-permission")
+	elem.Parent = doc.Root
+	if _, err := elem.SetAttr(ANDROID_NAMESPACE, "name", name); err != nil {
+		return nil, err
+	}
+	i := len(doc.Root.Children)
+	for j, child := range doc.Root.Children {
+		if e, ok := child.(*Element); ok && e.Name == "application" {
+			i = j
+			break
+		}
+	}
+	doc.Root.InsertChild(i, elem)
+	return elem, nil
+}
+
+// RemoveUsesPermission removes the <uses-permission> elements for name
+// from a manifest document, returning how many there were.
+func (doc *Document) RemoveUsesPermission(name string) int {
+	var n int
+	for _, elem := range doc.FindAll("uses-permission") {
+		if elem.AttrValue(ANDROID_NAMESPACE, "name") == name && doc.Root.RemoveChild(elem) {
+			n++
+		}
+	}
+	return n
+}
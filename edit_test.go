@@ -0,0 +1,201 @@
+package axmlParser
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestEditManifest(t *testing.T) {
+	doc, err := ParseXML(strings.NewReader(encoderManifest))
+	if err != nil {
+		t.Fatal(err)
+	}
+	app := doc.Find("application")
+	if _, err := app.SetAttr(ANDROID_NAMESPACE, "debuggable", "false"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := doc.Root.SetAttr(ANDROID_NAMESPACE, "versionCode", "13"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := doc.Root.SetAttr(ANDROID_NAMESPACE, "versionCode", "thirteen"); err == nil {
+		t.Error("SetAttr with an invalid value succeeded")
+	}
+	if !app.RemoveAttr(ANDROID_NAMESPACE, "allowBackup") || app.RemoveAttr(ANDROID_NAMESPACE, "allowBackup") {
+		t.Error("RemoveAttr did not remove the attribute once")
+	}
+	if err := doc.RenamePackage("com.example.white"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := doc.AddUsesPermission("android.permission.INTERNET"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := doc.AddUsesPermission("android.permission.CAMERA"); err != nil {
+		t.Fatal(err)
+	}
+	if n := doc.RemoveUsesPermission("android.permission.CAMERA"); n != 1 {
+		t.Errorf("RemoveUsesPermission = %d, want 1", n)
+	}
+	// unknown chunks are written back as they were
+	raw := &RawChunk{Type: 0x0199, Data: []byte{0x99, 0x01, 0x08, 0x00, 0x0c, 0x00, 0x00, 0x00, 1, 2, 3, 4}}
+	app.AppendChild(raw)
+	doc.Epilog = append(doc.Epilog, raw)
+
+	var bin bytes.Buffer
+	if err := Encode(&bin, doc); err != nil {
+		t.Fatal(err)
+	}
+	manifest, err := ParseManifest(bin.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if manifest.Package != "com.example.white" || manifest.VersionCode != 13 ||
+		manifest.Application.Debuggable || manifest.Application.AllowBackup != nil {
+		t.Errorf("manifest = %+v", manifest)
+	}
+	if name := manifest.Application.Activities[0].Name; name != "com.example.Main" {
+		t.Errorf("activity name = %q, want com.example.Main", name)
+	}
+	if len(manifest.UsesPermissions) != 1 || manifest.UsesPermissions[0].Name != "android.permission.INTERNET" {
+		t.Errorf("permissions = %+v", manifest.UsesPermissions)
+	}
+
+	decoded, err := ParseDocument(bin.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	children := decoded.Find("application").Children
+	last, ok := children[len(children)-1].(*RawChunk)
+	if !ok || !bytes.Equal(last.Data, raw.Data) {
+		t.Errorf("last child of application = %#v", children[len(children)-1])
+	}
+	if len(decoded.Epilog) != 1 || !bytes.Equal(decoded.Epilog[0].Data, raw.Data) {
+		t.Errorf("epilog = %#v", decoded.Epilog)
+	}
+	if elems := decoded.Root.Elements(); elems[2].Name != "uses-permission" || elems[3].Name != "application" {
+		t.Errorf("permission added at %s", elems[2].Name)
+	}
+}
+
+func TestEditBinaryManifest(t *testing.T) {
+	raw := le32(0x00080199, 12, 0x01020304)
+	// splice unknown chunks before the root, after its start tag and
+	// after its end tag
+	bin := binaryXML(t, encoderManifest)
+	var data []byte
+	starts, ends := 0, 0
+	for offset := 8; offset < len(bin); {
+		size := int(binary.LittleEndian.Uint32(bin[offset+4:]))
+		typ := binary.LittleEndian.Uint16(bin[offset:])
+		if typ == RES_XML_START_ELEMENT_TYPE && starts == 0 {
+			data = append(data, raw...)
+		}
+		data = append(data, bin[offset:offset+size]...)
+		switch typ {
+		case RES_XML_START_ELEMENT_TYPE:
+			if starts++; starts == 1 {
+				data = append(data, raw...)
+			}
+		case RES_XML_END_ELEMENT_TYPE:
+			if ends++; ends == starts {
+				data = append(data, raw...)
+			}
+		}
+		offset += size
+	}
+	data = append(le32(0x00080003, uint32(8+len(data))), data...)
+
+	doc, err := ParseDocument(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(doc.Prolog) != 1 || len(doc.Epilog) != 1 {
+		t.Fatalf("prolog %d chunks, epilog %d chunks", len(doc.Prolog), len(doc.Epilog))
+	}
+	if _, ok := doc.Root.Children[0].(*RawChunk); !ok {
+		t.Fatalf("first child of manifest = %#v", doc.Root.Children[0])
+	}
+
+	custom := &Attribute{Name: "custom", RawName: "custom", Namespace: "http://schemas.android.com/apk/res-auto",
+		Value: "old", Type: RES_TYPE_STRING, NameResourceID: 0x7f010000}
+	app := doc.Find("application")
+	app.Attrs = append(app.Attrs, custom)
+	if attr, err := app.SetAttr(custom.Namespace, "custom", "new"); err != nil || attr.NameResourceID != 0x7f010000 {
+		t.Errorf("SetAttr(custom) = %+v, %v", attr, err)
+	}
+	if _, err := doc.Root.SetAttr(ANDROID_NAMESPACE, "versionCode", "42"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := doc.AddUsesPermission("android.permission.INTERNET"); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := Encode(&out, doc); err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := ParseDocument(out.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded.Prolog) != 1 || len(decoded.Epilog) != 1 || !bytes.Equal(decoded.Prolog[0].Data, raw) {
+		t.Errorf("prolog %d chunks, epilog %d chunks", len(decoded.Prolog), len(decoded.Epilog))
+	}
+	if c, ok := decoded.Root.Children[0].(*RawChunk); !ok || !bytes.Equal(c.Data, raw) {
+		t.Errorf("first child of manifest = %#v", decoded.Root.Children[0])
+	}
+	if attr := decoded.Find("application").Attr(custom.Namespace, "custom"); attr == nil ||
+		attr.NameResourceID != 0x7f010000 || attr.Value != "new" {
+		t.Errorf("custom attribute = %+v", attr)
+	}
+	manifest, err := ParseManifest(out.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if manifest.VersionCode != 42 || len(manifest.UsesPermissions) != 1 {
+		t.Errorf("manifest = %+v", manifest)
+	}
+}
+
+func TestEditBounds(t *testing.T) {
+	elem := NewElement("", "manifest")
+	a, b, c := NewElement("", "a"), NewElement("", "b"), NewElement("", "c")
+	elem.InsertChild(5, a)
+	elem.InsertChild(-1, b)
+	elem.InsertChild(1, c)
+	names := ""
+	for _, e := range elem.Elements() {
+		names += e.Name
+	}
+	if names != "bca" || a.Parent != elem {
+		t.Errorf("children = %s", names)
+	}
+
+	doc := new(Document)
+	if _, err := doc.AddUsesPermission("android.permission.INTERNET"); err == nil {
+		t.Error("AddUsesPermission succeeded without a root element")
+	}
+	if err := doc.RenamePackage("com.example"); err == nil {
+		t.Error("RenamePackage succeeded without a root element")
+	}
+}
+
+func TestSetUnknownAttr(t *testing.T) {
+	elem := NewElement("", "uses-permission")
+	if _, err := elem.SetAttr(ANDROID_NAMESPACE, "usesPermissionFlags", "neverForLocation"); !errors.Is(err, ErrUnknownAttribute) {
+		t.Errorf("SetAttr of an unknown attribute: error = %v, want ErrUnknownAttribute", err)
+	}
+	if len(elem.Attrs) != 0 {
+		t.Errorf("attributes = %+v", elem.Attrs)
+	}
+
+	// an attribute read from a binary manifest has its ID already
+	elem.Attrs = append(elem.Attrs, &Attribute{Name: "usesPermissionFlags", RawName: "usesPermissionFlags",
+		Namespace: ANDROID_NAMESPACE, Value: "0x10000", Type: RES_TYPE_INT_HEX, NameResourceID: 0x01010644})
+	attr, err := elem.SetAttr(ANDROID_NAMESPACE, "usesPermissionFlags", "0x0")
+	if err != nil || attr.NameResourceID != 0x01010644 || len(elem.Attrs) != 1 {
+		t.Errorf("SetAttr of an attribute with an ID = %+v, %v", attr, err)
+	}
+}
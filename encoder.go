@@ -0,0 +1,414 @@
+package axmlParser
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"unicode/utf16"
+)
+
+const (
+	// NO_INDEX marks a missing string index, such as an attribute without
+	// a namespace
+	NO_INDEX = 0xFFFFFFFF
+
+	xmlNodeHeaderSize = 16
+	attrExtSize       = 20
+	attributeSize     = 20
+)
+
+// ParseXML builds a Document from text XML, typing attribute values the
+// way aapt2 does. Attributes of the android namespace get the resource ID
+// of the framework attribute and a value of its format. References must be
+// given by ID, such as @0x7f0b0021: symbolic ones like @string/app_name
+// need a resource table and are an error, as are attributes of the android
+// namespace with no known resource ID, which the package manager would
+// ignore. Whitespace only text is dropped.
+func ParseXML(r io.Reader) (*Document, error) {
+	doc := new(Document)
+	d := xml.NewDecoder(r)
+	for {
+		line, _ := d.InputPos()
+		token, err := d.Token()
+		if err == io.EOF {
+			return doc, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch token := token.(type) {
+		case xml.StartElement:
+			attrs := make([]*Attribute, 0)
+			for _, a := range token.Attr {
+				switch {
+				case a.Name.Space == "xmlns":
+					doc.addToken(StartNamespace{Prefix: a.Name.Local, URI: a.Value, Line: line})
+					continue
+				case a.Name.Space == "" && a.Name.Local == "xmlns":
+					doc.addToken(StartNamespace{URI: a.Value, Line: line})
+					continue
+				}
+
+				attr, err := newAttribute(a.Name.Space, a.Name.Local, a.Value, 0)
+				if err != nil {
+					return nil, err
+				}
+				attrs = append(attrs, attr)
+			}
+			doc.addToken(StartElement{Namespace: token.Name.Space, Name: token.Name.Local,
+				Attrs: attrs, Line: line})
+
+			// prefixes are known once the element holds its namespaces
+			for _, attr := range attrs {
+				attr.Prefix, _ = doc.current.LookupPrefix(attr.Namespace)
+			}
+		case xml.EndElement:
+			doc.addToken(EndElement{Namespace: token.Name.Space, Name: token.Name.Local, Line: line})
+		case xml.CharData:
+			if strings.TrimSpace(string(token)) != "" {
+				doc.addToken(CharData{Data: string(token), Line: line})
+			}
+		}
+	}
+}
+
+// newAttribute returns the attribute ns:name typed from its text value.
+// Attributes of the android namespace take the resource ID id, or the one
+// of the framework attribute when id is 0.
+func newAttribute(ns, name, value string, id int) (*Attribute, error) {
+	attr := &Attribute{
+		Name:           name,
+		RawName:        name,
+		Namespace:      ns,
+		Value:          value,
+		StringIndex:    -1,
+		NameResourceID: id,
+	}
+	if ns == ANDROID_NAMESPACE && id == 0 {
+		var ok bool
+		if attr.NameResourceID, ok = AndroidAttrID(name); !ok {
+			return nil, fmt.Errorf("%w: android:%s", ErrUnknownAttribute, name)
+		}
+	}
+	var err error
+	attr.Type, attr.Data, err = typedAttrValue(ns, name, value)
+	if err != nil {
+		return nil, err
+	}
+	if attr.Type != RES_TYPE_STRING {
+		attr.Value = formatTypedValue(attr.Type, attr.Data)
+	}
+	return attr, nil
+}
+
+// formatTypedValue formats a typed value like the parser does, for values
+// that need no string pool.
+func formatTypedValue(tpe, data int) string {
+	return New(nil).getAttributeValue(tpe, data)
+}
+
+// attrName is an attribute name of the string pool, kept apart from other
+// strings when it has a resource ID.
+type attrName struct {
+	name string
+	id   int
+}
+
+// axmlEncoder builds the chunks of a binary XML document.
+type axmlEncoder struct {
+	// names with a resource ID come first in the string pool, in the
+	// order of the resource map
+	names   []attrName
+	nameIdx map[attrName]int
+	strs    []string
+	strIdx  map[string]int
+
+	// namespaces declared on the root for URIs used without a declaration
+	extraNs []Namespace
+}
+
+// Encode writes doc as a binary XML document, as aapt2 compiles it: the
+// string pool, the resource map of attribute names, namespace chunks and
+// elements with their line numbers and typed attribute values. Chunks
+// the decoder did not understand are written back as they were.
+//
+// Attributes are written with their Type and Data, or as strings when
+// their Type is RES_TYPE_STRING or when they have a Value but no type.
+// Attributes with a resource ID come first in an element, ordered by ID.
+func Encode(w io.Writer, doc *Document) error {
+	e := &axmlEncoder{
+		nameIdx: make(map[attrName]int),
+		strIdx:  make(map[string]int),
+	}
+	if doc.Root != nil {
+		e.extraNs = undeclaredNamespaces(doc.Root)
+		e.collect(doc.Root)
+	}
+	e.indexStrings()
+
+	body := e.stringPool()
+	body = append(body, e.resourceMap()...)
+	for _, chunk := range doc.Prolog {
+		body = append(body, chunk.Data...)
+	}
+	if doc.Root != nil {
+		body = append(body, e.element(doc.Root)...)
+	}
+	for _, chunk := range doc.Epilog {
+		body = append(body, chunk.Data...)
+	}
+
+	header := putChunkHeader(nil, RES_XML_TYPE, CHUNK_HEADER_SIZE, CHUNK_HEADER_SIZE+len(body))
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+	_, err := w.Write(body)
+	return err
+}
+
+// namespaces returns the namespaces declared on elem.
+func (e *axmlEncoder) namespaces(elem *Element) []Namespace {
+	if elem.Parent == nil {
+		return append(append([]Namespace{}, elem.Namespaces...), e.extraNs...)
+	}
+	return elem.Namespaces
+}
+
+// collect gathers the strings of elem and its descendants.
+func (e *axmlEncoder) collect(elem *Element) {
+	for _, ns := range e.namespaces(elem) {
+		e.addString(ns.Prefix)
+		e.addString(ns.URI)
+	}
+	e.addString(elem.Namespace)
+	e.addString(elem.Name)
+	for _, attr := range sortedAttrs(elem) {
+		e.addString(attr.Namespace)
+		if id := attrResourceID(attr); id != 0 {
+			key := attrName{name: attr.Name, id: id}
+			if _, ok := e.nameIdx[key]; !ok {
+				e.nameIdx[key] = 0
+				e.names = append(e.names, key)
+			}
+		} else {
+			e.addString(attr.Name)
+		}
+		if _, ok := e.strIdx[attr.Value]; !ok && isStringAttr(attr) {
+			// string values are pooled even when empty
+			e.strIdx[attr.Value] = 0
+			e.strs = append(e.strs, attr.Value)
+		}
+	}
+	for _, child := range elem.Children {
+		switch c := child.(type) {
+		case *Element:
+			e.collect(c)
+		case *Text:
+			e.addString(c.Data)
+		}
+	}
+}
+
+func (e *axmlEncoder) addString(s string) {
+	if _, ok := e.strIdx[s]; !ok && s != "" {
+		e.strIdx[s] = 0
+		e.strs = append(e.strs, s)
+	}
+}
+
+// indexStrings orders names with resource IDs by ID, followed by the other
+// strings in document order.
+func (e *axmlEncoder) indexStrings() {
+	sort.SliceStable(e.names, func(i, j int) bool { return e.names[i].id < e.names[j].id })
+	for i, name := range e.names {
+		e.nameIdx[name] = i
+	}
+	for i, s := range e.strs {
+		e.strIdx[s] = len(e.names) + i
+	}
+}
+
+// index returns the pool index of s, or NO_INDEX for an empty string.
+func (e *axmlEncoder) index(s string) int {
+	if s == "" {
+		return NO_INDEX
+	}
+	return e.strIdx[s]
+}
+
+func (e *axmlEncoder) nameIndex(attr *Attribute) int {
+	if id := attrResourceID(attr); id != 0 {
+		return e.nameIdx[attrName{name: attr.Name, id: id}]
+	}
+	return e.index(attr.Name)
+}
+
+// sortedAttrs returns the attributes of elem with those having a resource
+// ID first, ordered by ID.
+func sortedAttrs(elem *Element) []*Attribute {
+	attrs := append([]*Attribute{}, elem.Attrs...)
+	sort.SliceStable(attrs, func(i, j int) bool {
+		a, b := attrResourceID(attrs[i]), attrResourceID(attrs[j])
+		return a != 0 && (b == 0 || a < b)
+	})
+	return attrs
+}
+
+// attrResourceID returns the resource ID of the attribute name, looking up
+// framework attributes by name when the attribute has none.
+func attrResourceID(attr *Attribute) int {
+	if attr.NameResourceID != 0 {
+		return attr.NameResourceID
+	}
+	if attr.Namespace == ANDROID_NAMESPACE {
+		id, _ := AndroidAttrID(attr.Name)
+		return id
+	}
+	return 0
+}
+
+func isStringAttr(attr *Attribute) bool {
+	return attr.Type == RES_TYPE_STRING ||
+		attr.Type == RES_TYPE_NULL && attr.Data == 0 && attr.Value != "" && attr.Value != "@null"
+}
+
+// stringPool encodes the strings as a UTF-16 string pool chunk.
+func (e *axmlEncoder) stringPool() []byte {
+	all := make([]string, 0, len(e.names)+len(e.strs))
+	for _, name := range e.names {
+		all = append(all, name.name)
+	}
+	all = append(all, e.strs...)
+
+	var offsets, data []byte
+	for _, s := range all {
+		offsets = putLEWord(offsets, len(data))
+		units := utf16.Encode([]rune(s))
+		if len(units) > 0x7FFF {
+			data = putLEShort(data, 0x8000|len(units)>>16)
+		}
+		data = putLEShort(data, len(units)&0xFFFF)
+		for _, u := range units {
+			data = putLEShort(data, int(u))
+		}
+		data = putLEShort(data, 0)
+	}
+	for len(data)%WORD_SIZE != 0 {
+		data = append(data, 0)
+	}
+
+	headerSize := 7 * WORD_SIZE
+	chunk := putChunkHeader(nil, RES_STRING_POOL_TYPE, headerSize, headerSize+len(offsets)+len(data))
+	chunk = putLEWord(chunk, len(all))
+	chunk = putLEWord(chunk, 0) // styles
+	chunk = putLEWord(chunk, 0) // flags, UTF-16
+	chunk = putLEWord(chunk, headerSize+len(offsets))
+	chunk = putLEWord(chunk, 0) // styles start
+	chunk = append(chunk, offsets...)
+	return append(chunk, data...)
+}
+
+// resourceMap encodes the resource IDs of the first strings of the pool.
+func (e *axmlEncoder) resourceMap() []byte {
+	if len(e.names) == 0 {
+		return nil
+	}
+	chunk := putChunkHeader(nil, RES_XML_RESOURCE_MAP_TYPE, CHUNK_HEADER_SIZE,
+		CHUNK_HEADER_SIZE+len(e.names)*WORD_SIZE)
+	for _, name := range e.names {
+		chunk = putLEWord(chunk, name.id)
+	}
+	return chunk
+}
+
+// node starts a ResXMLTree_node chunk, without comment.
+func node(typ, size, line int) []byte {
+	chunk := putChunkHeader(nil, typ, xmlNodeHeaderSize, size)
+	chunk = putLEWord(chunk, line)
+	return putLEWord(chunk, NO_INDEX)
+}
+
+// element encodes elem, its descendants and the namespaces it declares.
+func (e *axmlEncoder) element(elem *Element) []byte {
+	namespaces := e.namespaces(elem)
+	var out []byte
+	for _, ns := range namespaces {
+		out = append(out, node(RES_XML_START_NAMESPACE_TYPE, xmlNodeHeaderSize+8, elem.Line)...)
+		out = putLEWord(out, e.index(ns.Prefix))
+		out = putLEWord(out, e.index(ns.URI))
+	}
+
+	attrs := sortedAttrs(elem)
+	// 1-based indexes of the id, class and style attributes
+	special := map[string]int{"id": 0, "class": 0, "style": 0}
+	for i, attr := range attrs {
+		if _, ok := special[attr.Name]; ok && attr.Namespace == "" {
+			special[attr.Name] = i + 1
+		}
+	}
+
+	size := xmlNodeHeaderSize + attrExtSize + len(attrs)*attributeSize
+	out = append(out, node(RES_XML_START_ELEMENT_TYPE, size, elem.Line)...)
+	out = putLEWord(out, e.index(elem.Namespace))
+	out = putLEWord(out, e.index(elem.Name))
+	out = putLEShort(out, attrExtSize)
+	out = putLEShort(out, attributeSize)
+	out = putLEShort(out, len(attrs))
+	out = putLEShort(out, special["id"])
+	out = putLEShort(out, special["class"])
+	out = putLEShort(out, special["style"])
+	for _, attr := range attrs {
+		raw, tpe, data := NO_INDEX, attr.Type, attr.Data
+		if isStringAttr(attr) {
+			raw = e.strIdx[attr.Value]
+			tpe, data = RES_TYPE_STRING, raw
+		}
+		out = putLEWord(out, e.index(attr.Namespace))
+		out = putLEWord(out, e.nameIndex(attr))
+		out = putLEWord(out, raw)
+		out = putLEWord(out, tpe<<24|8) // size 8, res0 0, dataType
+		out = putLEWord(out, data)
+	}
+
+	for _, child := range elem.Children {
+		switch c := child.(type) {
+		case *Element:
+			out = append(out, e.element(c)...)
+		case *Text:
+			out = append(out, node(RES_XML_CDATA_TYPE, xmlNodeHeaderSize+12, c.Line)...)
+			out = putLEWord(out, e.index(c.Data))
+			out = putLEWord(out, 8) // Res_value, undefined
+			out = putLEWord(out, 0)
+		case *RawChunk:
+			out = append(out, c.Data...)
+		}
+	}
+
+	out = append(out, node(RES_XML_END_ELEMENT_TYPE, xmlNodeHeaderSize+8, elem.Line)...)
+	out = putLEWord(out, e.index(elem.Namespace))
+	out = putLEWord(out, e.index(elem.Name))
+
+	for i := len(namespaces) - 1; i >= 0; i-- {
+		out = append(out, node(RES_XML_END_NAMESPACE_TYPE, xmlNodeHeaderSize+8, elem.Line)...)
+		out = putLEWord(out, e.index(namespaces[i].Prefix))
+		out = putLEWord(out, e.index(namespaces[i].URI))
+	}
+	return out
+}
+
+func putChunkHeader(b []byte, typ, headerSize, size int) []byte {
+	b = putLEShort(b, typ)
+	b = putLEShort(b, headerSize)
+	return putLEWord(b, size)
+}
+
+func putLEWord(b []byte, v int) []byte {
+	return append(b, byte(v), byte(v>>8), byte(v>>16), byte(v>>24))
+}
+
+func putLEShort(b []byte, v int) []byte {
+	return append(b, byte(v), byte(v>>8))
+}
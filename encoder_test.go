@@ -0,0 +1,153 @@
+package axmlParser
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+const encoderManifest = `<?xml version="1.0" encoding="utf-8"?>
+<manifest xmlns:android="http://schemas.android.com/apk/res/android" package="com.example" android:versionCode="12" android:versionName="1.0">
+    <uses-sdk android:minSdkVersion="21" android:targetSdkVersion="34"/>
+    <permission android:name="com.example.READ" android:protectionLevel="signature|privileged"/>
+    <application android:label="Ünïcode 😀" android:icon="@0x7f010000" android:allowBackup="false" android:debuggable="true">
+        <activity android:name=".Main" android:launchMode="singleTop" android:screenOrientation="unspecified" android:exported="true">
+            <intent-filter android:priority="-5">
+                <action android:name="android.intent.action.MAIN"/>
+                <category android:name="android.intent.category.LAUNCHER"/>
+            </intent-filter>
+            <meta-data android:name="size" android:value="16dp"/>
+            <meta-data android:name="color" android:value="#80ff0000"/>
+            <meta-data android:name="empty" android:value=""/>
+        </activity>
+        <x>some &amp; text</x>
+    </application>
+</manifest>
+`
+
+// parseTestXML returns the Document of text XML, typed by ParseXML.
+func parseTestXML(t *testing.T, s string) *Document {
+	doc, err := ParseXML(strings.NewReader(s))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return doc
+}
+
+// binaryXML returns text XML compiled to binary XML.
+func binaryXML(t *testing.T, s string) []byte {
+	doc := parseTestXML(t, s)
+	var bin bytes.Buffer
+	if err := Encode(&bin, doc); err != nil {
+		t.Fatal(err)
+	}
+	return bin.Bytes()
+}
+
+func TestEncode(t *testing.T) {
+	doc, err := ParseXML(strings.NewReader(encoderManifest))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var bin bytes.Buffer
+	if err := Encode(&bin, doc); err != nil {
+		t.Fatal(err)
+	}
+
+	decoded, err := ParseDocument(bin.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// attributes come back ordered by resource ID, which encodes the same
+	var again, got bytes.Buffer
+	if err := Encode(&again, decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(again.Bytes(), bin.Bytes()) {
+		t.Error("encoding the decoded document differs")
+	}
+	decoded.WriteXML(&got, "    ")
+	if !strings.Contains(got.String(), `android:launchMode="1"`) ||
+		!strings.Contains(got.String(), `android:protectionLevel="0x00000012"`) ||
+		!strings.Contains(got.String(), `android:value="16dp"`) ||
+		!strings.Contains(got.String(), `android:versionName="1.0"`) {
+		t.Errorf("unexpected typed values:\n%s", got.String())
+	}
+	if line := decoded.Find("application/activity").Line; line != 6 {
+		t.Errorf("activity line = %d, want 6", line)
+	}
+
+	// the resource map covers the first strings of the pool, in ID order
+	parser := New(new(TreeListener))
+	if err := parser.Parse(bin.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+	for i, id := range parser.ResourcesIds {
+		if name, _ := AndroidAttrName(id); parser.StringsTable[i] != name {
+			t.Errorf("string %d = %q, resource 0x%08x", i, parser.StringsTable[i], id)
+		}
+		if i > 0 && id <= parser.ResourcesIds[i-1] {
+			t.Errorf("resource map not sorted at %d", i)
+		}
+	}
+
+	manifest, err := ParseManifest(bin.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if manifest.VersionCode != 12 || manifest.UsesSdk.Target != 34 ||
+		manifest.Application.Activities[0].IntentFilters[0].Priority != -5 ||
+		manifest.Application.Label != "Ünïcode 😀" {
+		t.Errorf("manifest = %+v", manifest)
+	}
+
+	for _, bad := range []string{
+		`<manifest xmlns:android="` + ANDROID_NAMESPACE + `" android:versionCode="one"/>`,
+		`<application xmlns:android="` + ANDROID_NAMESPACE + `" android:icon="@mipmap/ic_launcher"/>`,
+	} {
+		if _, err := ParseXML(strings.NewReader(bad)); err == nil {
+			t.Errorf("ParseXML(%s) succeeded", bad)
+		}
+	}
+	// the package manager ignores framework attributes without an ID
+	unknown := `<uses-permission xmlns:android="` + ANDROID_NAMESPACE + `"
+		android:name="android.permission.BLUETOOTH_SCAN" android:usesPermissionFlags="neverForLocation"/>`
+	if _, err := ParseXML(strings.NewReader(unknown)); !errors.Is(err, ErrUnknownAttribute) {
+		t.Errorf("ParseXML with an unknown attribute: error = %v, want ErrUnknownAttribute", err)
+	}
+}
+
+func TestTypedValues(t *testing.T) {
+	tests := []struct {
+		value string
+		tpe   int
+		data  int
+	}{
+		{"@null", RES_TYPE_REFERENCE, 0},
+		{"?0x01010036", RES_TYPE_ATTRIBUTE, 0x01010036},
+		{"true", RES_TYPE_INT_BOOLEAN, 0xFFFFFFFF},
+		{"-1", RES_TYPE_INT_DEC, 0xFFFFFFFF},
+		{"0x00000010", RES_TYPE_INT_HEX, 0x10},
+		{"1.5", RES_TYPE_FLOAT, 0x3fc00000},
+		{"#f0c", RES_TYPE_INT_COLOR_RGB4, 0xffff00cc},
+		{"#1234", RES_TYPE_INT_COLOR_ARGB4, 0x11223344},
+		{"0.5mm", RES_TYPE_DIMENSION, 0x40000035},
+		{"-2dp", RES_TYPE_DIMENSION, 0xfffffe01},
+		{"100%", RES_TYPE_FRACTION, 0x00000100},
+		{"25%p", RES_TYPE_FRACTION, 0x20000031},
+		{"text", RES_TYPE_STRING, 0},
+	}
+	for _, test := range tests {
+		tpe, data, ok := parseTypedValue(test.value, attrFormat{format: FORMAT_ANY})
+		if !ok || tpe != test.tpe || data != test.data {
+			t.Errorf("parseTypedValue(%q) = 0x%02x 0x%08x %v, want 0x%02x 0x%08x",
+				test.value, tpe, data, ok, test.tpe, test.data)
+		}
+		if tpe != RES_TYPE_STRING && test.value[0] != '?' {
+			if got := formatTypedValue(tpe, data); got != test.value && test.value != "-1" {
+				t.Errorf("format(%q) = %q", test.value, got)
+			}
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package axmlParser
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrTruncated is reported when a read goes past the end of the data.
+	ErrTruncated = errors.New("axmlParser: truncated data")
+	// ErrBadStringPool is reported when the string pool is malformed.
+	ErrBadStringPool = errors.New("axmlParser: malformed string pool")
+	// ErrBadChunk is reported when a chunk declares an impossible size.
+	ErrBadChunk = errors.New("axmlParser: malformed chunk")
+	// ErrUnresolved is reported when a resource reference has no value.
+	ErrUnresolved = errors.New("axmlParser: unresolved resource reference")
+	// ErrNoIcon is reported when the application declares no icon.
+	ErrNoIcon = errors.New("axmlParser: application has no icon")
+	// ErrUnknownAttribute is reported when encoding an attribute of the
+	// android namespace with no known resource ID.
+	ErrUnknownAttribute = errors.New("axmlParser: unknown framework attribute")
+	// ErrNoManifest is reported when an apk has no AndroidManifest.xml.
+	ErrNoManifest = errors.New("axmlParser: no AndroidManifest.xml in apk")
+	// ErrEncryptedEntry is reported when a zip entry is encrypted.
+	ErrEncryptedEntry = errors.New("axmlParser: encrypted zip entry")
+	// ErrUnsupportedCompression is reported when a zip entry is compressed
+	// with a method other than store and deflate.
+	ErrUnsupportedCompression = errors.New("axmlParser: unsupported zip compression method")
+	// ErrSizeMismatch is reported when the content of a zip entry does not
+	// match its declared size.
+	ErrSizeMismatch = errors.New("axmlParser: zip entry size mismatch")
+)
+
+// ParseError describes where and why decoding a binary XML document failed.
+// Err holds one of the sentinel errors above so callers can use errors.Is.
+type ParseError struct {
+	Offset int
+	Chunk  int
+	Reason string
+	Err    error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("axmlParser: %s at offset %d (chunk 0x%04X): %v",
+		e.Reason, e.Offset, e.Chunk, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
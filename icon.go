@@ -0,0 +1,183 @@
+package axmlParser
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// iconSdkVersion stands for a device newer than any resource qualifier, so
+// adaptive icons are picked over their bitmap fallbacks.
+const iconSdkVersion = 0xFFFF
+
+// Drawable is a drawable resource resolved to a file of the apk, or to a
+// color.
+type Drawable struct {
+	ResourceID int
+	// Path is the file in the apk, empty for colors
+	Path string
+	// Data is the content of Path: PNG or WebP bytes for bitmaps, binary
+	// XML for others
+	Data  []byte
+	Color string
+}
+
+// Icon is an application icon. Adaptive is set for adaptive icons, whose
+// Data is the binary XML referencing the layers.
+type Icon struct {
+	Drawable
+	Adaptive *AdaptiveIcon
+}
+
+// AdaptiveIcon holds the layers of an adaptive-icon drawable, nil when
+// missing or not given as a reference or a color.
+type AdaptiveIcon struct {
+	Background *Drawable
+	Foreground *Drawable
+	Monochrome *Drawable
+}
+
+// ExtractIcon returns the icon of the apk for a screen of the given
+// density, such as DENSITY_XXHIGH, reading it from the zip.
+func ExtractIcon(apkpath string, density int) (*Icon, error) {
+	return extractIcon(apkpath, density, "icon")
+}
+
+// ExtractRoundIcon is like ExtractIcon for android:roundIcon, falling back
+// to android:icon when the application has no round icon.
+func ExtractRoundIcon(apkpath string, density int) (*Icon, error) {
+	icon, err := extractIcon(apkpath, density, "roundIcon")
+	if errors.Is(err, ErrNoIcon) {
+		return extractIcon(apkpath, density, "icon")
+	}
+	return icon, err
+}
+
+func extractIcon(apkpath string, density int, name string) (*Icon, error) {
+	r, err := zip.OpenReader(apkpath)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	manifest, table, tableErr := readApk(&r.Reader)
+	if manifest == nil {
+		return nil, tableErr
+	}
+	doc, err := ParseDocument(manifest)
+	if err != nil {
+		return nil, err
+	}
+	// the icon is found through the resource table
+	if tableErr != nil {
+		return nil, tableErr
+	}
+	if table == nil {
+		return nil, errors.New("axmlParser: no resources.arsc in apk")
+	}
+
+	read := func(name string) ([]byte, error) {
+		data, err := readZipFile(&r.Reader, name)
+		if err == nil && data == nil {
+			err = fmt.Errorf("axmlParser: no %s in apk", name)
+		}
+		return data, err
+	}
+	config := ResourceConfig{Density: density, SdkVersion: iconSdkVersion}
+	return table.loadIcon(doc, name, &config, read)
+}
+
+// loadIcon resolves the icon attribute name of the application in doc.
+func (table *ResourceTable) loadIcon(doc *Document, name string, config *ResourceConfig,
+	read func(string) ([]byte, error)) (*Icon, error) {
+	var attr *Attribute
+	if app := doc.Find("application"); app != nil {
+		attr = app.Attr(ANDROID_NAMESPACE, name)
+	}
+	if attr == nil {
+		return nil, fmt.Errorf("%w: no android:%s", ErrNoIcon, name)
+	}
+	id, err := attr.ResourceID()
+	if err != nil {
+		return nil, fmt.Errorf("%w: android:%s is %q", ErrNoIcon, name, attr.Value)
+	}
+
+	drawable, err := table.loadDrawable(id, config, read)
+	if err != nil {
+		return nil, err
+	}
+	icon := &Icon{Drawable: *drawable}
+	if strings.HasSuffix(icon.Path, ".xml") {
+		xml, err := ParseDocument(icon.Data)
+		if err != nil {
+			return nil, err
+		}
+		if xml.Root != nil && xml.Root.Name == "adaptive-icon" {
+			icon.Adaptive, err = table.loadAdaptiveIcon(xml, config, read)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return icon, nil
+}
+
+// loadDrawable resolves the drawable id for config and reads its file.
+func (table *ResourceTable) loadDrawable(id int, config *ResourceConfig,
+	read func(string) ([]byte, error)) (*Drawable, error) {
+	value, err := table.ResolveConfig(id, config)
+	if err != nil {
+		return nil, err
+	}
+
+	drawable := &Drawable{ResourceID: id}
+	switch {
+	case value.Type == RES_TYPE_STRING:
+		drawable.Path = table.FormatValue(value)
+		if drawable.Data, err = read(drawable.Path); err != nil {
+			return nil, err
+		}
+	case value.Type >= RES_TYPE_FIRST_COLOR_INT && value.Type <= RES_TYPE_LAST_COLOR_INT:
+		drawable.Color = table.FormatValue(value)
+	default:
+		return nil, fmt.Errorf("axmlParser: drawable 0x%08x is %s", id, table.FormatValue(value))
+	}
+	return drawable, nil
+}
+
+// loadAdaptiveIcon resolves the layers of an adaptive-icon document.
+func (table *ResourceTable) loadAdaptiveIcon(doc *Document, config *ResourceConfig,
+	read func(string) ([]byte, error)) (*AdaptiveIcon, error) {
+	layer := func(name string) (*Drawable, error) {
+		elem := doc.Find(name)
+		if elem == nil {
+			return nil, nil
+		}
+		attr := elem.Attr(ANDROID_NAMESPACE, "drawable")
+		if attr == nil {
+			// an inline drawable, such as an inset, has no reference
+			return nil, nil
+		}
+		if id, err := attr.ResourceID(); err == nil {
+			return table.loadDrawable(id, config, read)
+		}
+		if attr.Type >= RES_TYPE_FIRST_COLOR_INT && attr.Type <= RES_TYPE_LAST_COLOR_INT {
+			return &Drawable{Color: attr.Value}, nil
+		}
+		return nil, nil
+	}
+
+	var adaptive AdaptiveIcon
+	var err error
+	if adaptive.Background, err = layer("background"); err != nil {
+		return nil, err
+	}
+	if adaptive.Foreground, err = layer("foreground"); err != nil {
+		return nil, err
+	}
+	if adaptive.Monochrome, err = layer("monochrome"); err != nil {
+		return nil, err
+	}
+	return &adaptive, nil
+}
@@ -0,0 +1,133 @@
+package axmlParser
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// testIconTable returns a table with:
+//
+//	0x7f010000 mipmap/ic_launcher, in mdpi, xxhdpi and anydpi-v26
+//	0x7f020001 drawable/ic_fg
+//	0x7f030002 color/bg #ff00ff00
+func testIconTable() []byte {
+	config := func(density, sdk int) []byte {
+		config := testConfig("", sdk)
+		binary.LittleEndian.PutUint16(config[14:], uint16(density))
+		return config
+	}
+	path := func(s uint32) []byte {
+		return le32(0x00000008, 0, 0x03000008, s)
+	}
+
+	pkg := testPackage([]string{"mipmap", "drawable", "color"}, []string{"ic_launcher", "ic_fg", "bg"},
+		testTypeChunk(1, config(DENSITY_MEDIUM, 4), path(0)),
+		testTypeChunk(1, config(DENSITY_XXHIGH, 4), path(1)),
+		testTypeChunk(1, config(DENSITY_ANY, 26), path(2)),
+		testTypeChunk(2, config(0, 0), nil, path(3)),
+		testTypeChunk(3, config(0, 0), nil, nil, le32(0x00000008, 2, 0x1c000008, 0xff00ff00)))
+	return testTable([]string{"res/mipmap-mdpi/ic_launcher.png", "res/mipmap-xxhdpi/ic_launcher.png",
+		"res/mipmap-anydpi-v26/ic_launcher.xml", "res/drawable/ic_fg.png"}, pkg)
+}
+
+func TestLoadIcon(t *testing.T) {
+	table, err := ParseResourceTable(testIconTable())
+	if err != nil {
+		t.Fatal(err)
+	}
+	read := func(name string) ([]byte, error) {
+		return []byte("data of " + name), nil
+	}
+	manifest := parseTestXML(t, `<manifest xmlns:android="`+ANDROID_NAMESPACE+`">
+		<application android:icon="@0x7f010000" android:label="Example"/></manifest>`)
+
+	tests := []struct {
+		density, sdk int
+		want         string
+	}{
+		{DENSITY_MEDIUM, 21, "res/mipmap-mdpi/ic_launcher.png"},
+		{DENSITY_HIGH, 21, "res/mipmap-xxhdpi/ic_launcher.png"},
+		{DENSITY_XXXHIGH, 21, "res/mipmap-xxhdpi/ic_launcher.png"},
+	}
+	for _, test := range tests {
+		config := ResourceConfig{Density: test.density, SdkVersion: test.sdk}
+		icon, err := table.loadIcon(manifest, "icon", &config, read)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if icon.Path != test.want || string(icon.Data) != "data of "+test.want {
+			t.Errorf("icon for %ddpi = %q, want %q", test.density, icon.Path, test.want)
+		}
+	}
+
+	config := ResourceConfig{Density: DENSITY_XXHIGH, SdkVersion: iconSdkVersion}
+	if _, err := table.loadIcon(manifest, "roundIcon", &config, read); err == nil {
+		t.Error("loadIcon(roundIcon) succeeded without a round icon")
+	}
+	icon, err := table.loadDrawable(0x7f010000, &config, read)
+	if err != nil || icon.Path != "res/mipmap-anydpi-v26/ic_launcher.xml" {
+		t.Fatalf("adaptive icon = %+v, %v", icon, err)
+	}
+
+	adaptiveXML := parseTestXML(t, `<adaptive-icon xmlns:android="`+ANDROID_NAMESPACE+`">
+		<background android:drawable="@0x7f030002"/>
+		<foreground android:drawable="@0x7f020001"/>
+		<monochrome android:drawable="#ff000000"/></adaptive-icon>`)
+	adaptive, err := table.loadAdaptiveIcon(adaptiveXML, &config, read)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := fmt.Sprintf("%s|%s|%s", adaptive.Background.Color, adaptive.Foreground.Path,
+		adaptive.Monochrome.Color)
+	if want := "#ff00ff00|res/drawable/ic_fg.png|#ff000000"; got != want {
+		t.Errorf("adaptive layers = %s, want %s", got, want)
+	}
+}
+
+func TestExtractIcon(t *testing.T) {
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	add := func(name string, data []byte) {
+		fw, err := w.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fw.Write(data)
+	}
+	add("AndroidManifest.xml", binaryXML(t, `<manifest xmlns:android="`+ANDROID_NAMESPACE+`" package="com.example">
+		<application android:icon="@0x7f010000"/></manifest>`))
+	add("resources.arsc", testIconTable())
+	add("res/mipmap-anydpi-v26/ic_launcher.xml", binaryXML(t, `<adaptive-icon xmlns:android="`+ANDROID_NAMESPACE+`">
+		<background android:drawable="@0x7f030002"/>
+		<foreground android:drawable="@0x7f020001"/></adaptive-icon>`))
+	add("res/drawable/ic_fg.png", []byte("foreground"))
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	apk := filepath.Join(t.TempDir(), "icon.apk")
+	if err := os.WriteFile(apk, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, extract := range []func(string, int) (*Icon, error){ExtractIcon, ExtractRoundIcon} {
+		icon, err := extract(apk, DENSITY_XXHIGH)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if icon.Path != "res/mipmap-anydpi-v26/ic_launcher.xml" || icon.Adaptive == nil {
+			t.Fatalf("icon = %+v", icon)
+		}
+		fg, bg := icon.Adaptive.Foreground, icon.Adaptive.Background
+		if fg == nil || string(fg.Data) != "foreground" || bg == nil || bg.Color != "#ff00ff00" {
+			t.Errorf("adaptive layers = %+v, %+v", fg, bg)
+		}
+		if icon.Adaptive.Monochrome != nil {
+			t.Errorf("monochrome = %+v, want nil", icon.Adaptive.Monochrome)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package axmlParser
+
+const (
+	ACTION_MAIN = "android.intent.action.MAIN"
+
+	CATEGORY_LAUNCHER          = "android.intent.category.LAUNCHER"
+	CATEGORY_LEANBACK_LAUNCHER = "android.intent.category.LEANBACK_LAUNCHER"
+	CATEGORY_CAR_LAUNCHER      = "android.intent.category.CAR_LAUNCHER"
+)
+
+// launcherCategories are the categories that make a MAIN activity show in
+// a launcher: phones, TVs and cars.
+var launcherCategories = []string{
+	CATEGORY_LAUNCHER,
+	CATEGORY_LEANBACK_LAUNCHER,
+	CATEGORY_CAR_LAUNCHER,
+}
+
+// LaunchableActivity is an activity, or activity alias, that a launcher
+// shows, like the launchable-activity lines of aapt dump badging. Names are
+// fully qualified, and Category is the launcher category it was found for.
+type LaunchableActivity struct {
+	Name           string
+	TargetActivity string
+	Label, Icon    string
+	Category       string
+}
+
+// LaunchableActivities returns the enabled activities and activity aliases
+// handling MAIN with one of the launcher categories. An activity appears
+// once per launcher category it declares.
+func (manifest *AndroidManifest) LaunchableActivities() []LaunchableActivity {
+	app := &manifest.Application
+	launchables := make([]LaunchableActivity, 0)
+	if !isEnabled(app.Enabled) {
+		return launchables
+	}
+
+	add := func(c *Component, target *Component) {
+		label, icon := c.Label, c.Icon
+		targetName := ""
+		if target != nil {
+			label = firstNonEmpty(label, target.Label)
+			icon = firstNonEmpty(icon, target.Icon)
+			targetName = manifest.ClassName(target.Name)
+		}
+		for _, category := range launcherCategories {
+			if !c.handles(ACTION_MAIN, category) {
+				continue
+			}
+			launchables = append(launchables, LaunchableActivity{
+				Name:           manifest.ClassName(c.Name),
+				TargetActivity: targetName,
+				Label:          firstNonEmpty(label, app.Label),
+				Icon:           firstNonEmpty(icon, app.Icon),
+				Category:       category,
+			})
+		}
+	}
+
+	for i := range app.Activities {
+		if activity := &app.Activities[i].Component; isEnabled(activity.Enabled) {
+			add(activity, nil)
+		}
+	}
+	for i := range app.ActivityAliases {
+		alias := &app.ActivityAliases[i]
+		if !isEnabled(alias.Enabled) {
+			continue
+		}
+		// an alias only launches through an enabled target activity
+		target := manifest.findActivity(alias.TargetActivity)
+		if target == nil || !isEnabled(target.Enabled) {
+			continue
+		}
+		add(&alias.Component, target)
+	}
+
+	return launchables
+}
+
+// LauncherActivity returns the first activity shown in the phone launcher,
+// or nil if the application has none.
+func (manifest *AndroidManifest) LauncherActivity() *LaunchableActivity {
+	for _, launchable := range manifest.LaunchableActivities() {
+		if launchable.Category == CATEGORY_LAUNCHER {
+			return &launchable
+		}
+	}
+	return nil
+}
+
+// findActivity returns the activity with the given, possibly relative,
+// class name.
+func (manifest *AndroidManifest) findActivity(name string) *Component {
+	name = manifest.ClassName(name)
+	for i := range manifest.Application.Activities {
+		activity := &manifest.Application.Activities[i].Component
+		if manifest.ClassName(activity.Name) == name {
+			return activity
+		}
+	}
+	return nil
+}
+
+// handles reports whether an intent filter of c has both action and
+// category.
+func (c *Component) handles(action, category string) bool {
+	for _, filter := range c.IntentFilters {
+		if containsString(filter.Actions, action) && containsString(filter.Categories, category) {
+			return true
+		}
+	}
+	return false
+}
+
+// isEnabled reads an android:enabled value, which defaults to true.
+func isEnabled(enabled *bool) bool {
+	return enabled == nil || *enabled
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,123 @@
+package axmlParser
+
+import (
+	"bytes"
+	"testing"
+)
+
+const launcherManifest = `<manifest xmlns:android="http://schemas.android.com/apk/res/android" package="com.example">
+  <application android:label="App" android:icon="@0x7f0d0000">
+    <activity android:name=".Disabled" android:enabled="false">
+      <intent-filter>
+        <action android:name="android.intent.action.MAIN"/>
+        <category android:name="android.intent.category.LAUNCHER"/>
+      </intent-filter>
+    </activity>
+    <activity android:name="Settings">
+      <intent-filter>
+        <action android:name="android.intent.action.MAIN"/>
+      </intent-filter>
+    </activity>
+    <activity android:name=".ui.Main" android:label="Main">
+      <intent-filter>
+        <action android:name="android.intent.action.MAIN"/>
+        <category android:name="android.intent.category.LAUNCHER"/>
+        <category android:name="android.intent.category.LEANBACK_LAUNCHER"/>
+      </intent-filter>
+    </activity>
+    <activity-alias android:name="com.example.Alias" android:targetActivity=".ui.Main">
+      <intent-filter>
+        <action android:name="android.intent.action.MAIN"/>
+        <category android:name="android.intent.category.CAR_LAUNCHER"/>
+      </intent-filter>
+    </activity-alias>
+  </application>
+</manifest>`
+
+func TestLaunchableActivities(t *testing.T) {
+	manifest, err := NewAndroidManifest(parseTestXML(t, launcherManifest))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []LaunchableActivity{
+		{Name: "com.example.ui.Main", Label: "Main", Icon: "@0x7f0d0000", Category: CATEGORY_LAUNCHER},
+		{Name: "com.example.ui.Main", Label: "Main", Icon: "@0x7f0d0000", Category: CATEGORY_LEANBACK_LAUNCHER},
+		{Name: "com.example.Alias", TargetActivity: "com.example.ui.Main", Label: "Main",
+			Icon: "@0x7f0d0000", Category: CATEGORY_CAR_LAUNCHER},
+	}
+	got := manifest.LaunchableActivities()
+	if len(got) != len(want) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("launchable %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	if launcher := manifest.LauncherActivity(); launcher == nil || launcher.Name != "com.example.ui.Main" {
+		t.Errorf("LauncherActivity() = %+v", launcher)
+	}
+}
+
+func TestAppNameListener(t *testing.T) {
+	doc := parseTestXML(t, `<manifest xmlns:android="`+ANDROID_NAMESPACE+`" package="com.example">
+  <uses-sdk android:minSdkVersion="Q"/>
+  <application android:label="App">
+    <activity android:name=".Main">
+      <intent-filter>
+        <action android:name="android.intent.action.MAIN"/>
+        <category android:name="android.intent.category.LAUNCHER"/>
+      </intent-filter>
+    </activity>
+  </application>
+</manifest>`)
+	parse := func() *AppNameListener {
+		var bin bytes.Buffer
+		if err := Encode(&bin, doc); err != nil {
+			t.Fatal(err)
+		}
+		listener := new(AppNameListener)
+		if err := New(listener).Parse(bin.Bytes()); err != nil {
+			t.Fatal(err)
+		}
+		return listener
+	}
+
+	listener := parse()
+	if listener.ActivityName != "com.example.Main" || listener.Label != "App" || listener.Err != nil {
+		t.Errorf("codename SDK: listener = %+v", listener)
+	}
+
+	// a version code the model cannot store still finds the launcher
+	code := &Attribute{Name: "versionCode", Namespace: ANDROID_NAMESPACE, Prefix: "android",
+		Value: "seven", Type: RES_TYPE_STRING, NameResourceID: 0x0101021b}
+	doc.Root.Attrs = append(doc.Root.Attrs, code)
+	listener = parse()
+	if listener.ActivityName != "com.example.Main" || listener.Label != "App" || listener.Err == nil {
+		t.Errorf("bad version code: listener = %+v", listener)
+	}
+}
+
+func TestResolveClassName(t *testing.T) {
+	tests := []struct{ pkg, name, want string }{
+		{"com.example", ".ui.Main", "com.example.ui.Main"},
+		{"com.example", "Main", "com.example.Main"},
+		{"com.example", "org.other.Main", "org.other.Main"},
+		{"com.example", "", ""},
+	}
+	for _, test := range tests {
+		if got := ResolveClassName(test.pkg, test.name); got != test.want {
+			t.Errorf("ResolveClassName(%q, %q) = %q, want %q", test.pkg, test.name, got, test.want)
+		}
+	}
+
+	manifest := &AndroidManifest{Package: "com.example.app", Namespace: "com.example"}
+	manifest.Application.Name = ".App"
+	manifest.Application.Services = []Service{{Component: Component{Name: "Sync"}}}
+	names := manifest.ClassNames()
+	if names.Application != "com.example.App" || names.Services[0] != "com.example.Sync" {
+		t.Errorf("ClassNames() = %+v", names)
+	}
+}
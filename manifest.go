@@ -0,0 +1,195 @@
+package axmlParser
+
+import (
+	"strconv"
+	"strings"
+)
+
+// AndroidManifest is the typed model of an AndroidManifest.xml file.
+type AndroidManifest struct {
+	Package string `axml:"package,attr"`
+	// Namespace is the package class names are relative to, when newer
+	// build tools set it apart from Package
+	Namespace   string `axml:"namespace,attr"`
+	VersionName string `axml:"android:versionName,attr"`
+	// VersionCode combines versionCodeMajor, in the upper 32 bits, with
+	// versionCode
+	VersionCode int64
+
+	UsesSdk              UsesSdk          `axml:"uses-sdk"`
+	UsesPermissions      []UsesPermission `axml:"uses-permission"`
+	UsesPermissionsSdk23 []UsesPermission `axml:"uses-permission-sdk-23"`
+	Permissions          []Permission     `axml:"permission"`
+	UsesFeatures         []UsesFeature    `axml:"uses-feature"`
+	UsesLibraries        []UsesLibrary    `axml:"application>uses-library"`
+	Application          Application      `axml:"application"`
+}
+
+// UsesSdk holds the API levels of uses-sdk. Preview releases are given by
+// codename, such as "Q", in MinCodename and TargetCodename, leaving Min and
+// Target to 0.
+type UsesSdk struct {
+	Min            int
+	Target         int
+	Max            int `axml:"android:maxSdkVersion,attr"`
+	MinCodename    string
+	TargetCodename string
+}
+
+type UsesPermission struct {
+	Name          string `axml:"android:name,attr"`
+	MaxSdkVersion int    `axml:"android:maxSdkVersion,attr"`
+}
+
+type Permission struct {
+	Name            string `axml:"android:name,attr"`
+	Label           string `axml:"android:label,attr"`
+	PermissionGroup string `axml:"android:permissionGroup,attr"`
+	ProtectionLevel int    `axml:"android:protectionLevel,attr"`
+}
+
+type UsesFeature struct {
+	Name        string `axml:"android:name,attr"`
+	Required    *bool  `axml:"android:required,attr"`
+	GlEsVersion int    `axml:"android:glEsVersion,attr"`
+}
+
+type UsesLibrary struct {
+	Name     string `axml:"android:name,attr"`
+	Required *bool  `axml:"android:required,attr"`
+}
+
+type Application struct {
+	Name        string `axml:"android:name,attr"`
+	Label       string `axml:"android:label,attr"`
+	Icon        string `axml:"android:icon,attr"`
+	RoundIcon   string `axml:"android:roundIcon,attr"`
+	Theme       string `axml:"android:theme,attr"`
+	Enabled     *bool  `axml:"android:enabled,attr"`
+	Debuggable  bool   `axml:"android:debuggable,attr"`
+	AllowBackup *bool  `axml:"android:allowBackup,attr"`
+
+	Activities      []Activity      `axml:"activity"`
+	ActivityAliases []ActivityAlias `axml:"activity-alias"`
+	Services        []Service       `axml:"service"`
+	Receivers       []Receiver      `axml:"receiver"`
+	Providers       []Provider      `axml:"provider"`
+	MetaData        []MetaData      `axml:"meta-data"`
+}
+
+// Component holds what activities, services, receivers and providers have
+// in common. Enabled and Exported are nil when not set in the manifest.
+type Component struct {
+	Name       string `axml:"android:name,attr"`
+	Label      string `axml:"android:label,attr"`
+	Icon       string `axml:"android:icon,attr"`
+	Enabled    *bool  `axml:"android:enabled,attr"`
+	Exported   *bool  `axml:"android:exported,attr"`
+	Permission string `axml:"android:permission,attr"`
+	Process    string `axml:"android:process,attr"`
+
+	IntentFilters []IntentFilter `axml:"intent-filter"`
+	MetaData      []MetaData     `axml:"meta-data"`
+}
+
+type Activity struct {
+	Component
+	Theme      string `axml:"android:theme,attr"`
+	LaunchMode int    `axml:"android:launchMode,attr"`
+}
+
+type ActivityAlias struct {
+	Component
+	TargetActivity string `axml:"android:targetActivity,attr"`
+}
+
+type Service struct {
+	Component
+	ForegroundServiceType int `axml:"android:foregroundServiceType,attr"`
+}
+
+type Receiver struct {
+	Component
+}
+
+type Provider struct {
+	Component
+	Authorities         string `axml:"android:authorities,attr"`
+	GrantUriPermissions bool   `axml:"android:grantUriPermissions,attr"`
+}
+
+type IntentFilter struct {
+	Priority   int          `axml:"android:priority,attr"`
+	Actions    []string     `axml:"action>android:name,attr"`
+	Categories []string     `axml:"category>android:name,attr"`
+	Data       []IntentData `axml:"data"`
+}
+
+type IntentData struct {
+	Scheme      string `axml:"android:scheme,attr"`
+	Host        string `axml:"android:host,attr"`
+	Port        string `axml:"android:port,attr"`
+	Path        string `axml:"android:path,attr"`
+	PathPrefix  string `axml:"android:pathPrefix,attr"`
+	PathPattern string `axml:"android:pathPattern,attr"`
+	MimeType    string `axml:"android:mimeType,attr"`
+}
+
+type MetaData struct {
+	Name     string `axml:"android:name,attr"`
+	Value    string `axml:"android:value,attr"`
+	Resource string `axml:"android:resource,attr"`
+}
+
+// ParseManifest decodes a binary AndroidManifest.xml into its typed model.
+func ParseManifest(data []byte) (*AndroidManifest, error) {
+	doc, err := ParseDocument(data)
+	if err != nil {
+		return nil, err
+	}
+	return NewAndroidManifest(doc)
+}
+
+// NewAndroidManifest builds the typed model of a parsed manifest.
+func NewAndroidManifest(doc *Document) (*AndroidManifest, error) {
+	manifest := new(AndroidManifest)
+	if doc.Root == nil {
+		return manifest, nil
+	}
+	if err := doc.Root.Unmarshal(manifest); err != nil {
+		return nil, err
+	}
+
+	var version struct {
+		Code  uint32 `axml:"android:versionCode,attr"`
+		Major uint32 `axml:"android:versionCodeMajor,attr"`
+	}
+	if err := doc.Root.Unmarshal(&version); err != nil {
+		return nil, err
+	}
+	manifest.VersionCode = int64(version.Major)<<32 | int64(version.Code)
+
+	if sdk := doc.Root.Find("uses-sdk"); sdk != nil {
+		sdkVersion(sdk.Attr(ANDROID_NAMESPACE, "minSdkVersion"), &manifest.UsesSdk.Min, &manifest.UsesSdk.MinCodename)
+		sdkVersion(sdk.Attr(ANDROID_NAMESPACE, "targetSdkVersion"), &manifest.UsesSdk.Target, &manifest.UsesSdk.TargetCodename)
+	}
+	return manifest, nil
+}
+
+// sdkVersion stores the API level of attr in level, or its codename in
+// codename for preview releases. References are left unresolved.
+func sdkVersion(attr *Attribute, level *int, codename *string) {
+	switch {
+	case attr == nil:
+	case attr.Type == RES_TYPE_STRING:
+		if n, err := strconv.Atoi(strings.TrimSpace(attr.Value)); err == nil {
+			*level = n
+		} else {
+			*codename = attr.Value
+		}
+	default:
+		if n, err := attr.Int(); err == nil {
+			*level = n
+		}
+	}
+}
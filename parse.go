@@ -2,6 +2,11 @@ package axmlParser
 
 import (
 	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
 	"io/ioutil"
 )
 
@@ -11,40 +16,155 @@ func ParseApk(apkpath string, listener Listener) (*Parser, error) {
 		return nil, err
 	}
 	defer r.Close()
+	return parseApkZip(&r.Reader, listener)
+}
 
-	var xmlf *zip.File
+// ParseApkReader parses the manifest of the apk held by r, which is size
+// bytes long, such as an upload kept in object storage.
+func ParseApkReader(r io.ReaderAt, size int64, listener Listener) (*Parser, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, err
+	}
+	return parseApkZip(zr, listener)
+}
 
-	for _, f := range r.File {
-		if f.Name != "AndroidManifest.xml" {
-			continue
-		}
-		xmlf = f
-		break
+// ParseApkBytes parses the manifest of the apk held in memory.
+func ParseApkBytes(data []byte, listener Listener) (*Parser, error) {
+	return ParseApkReader(bytes.NewReader(data), int64(len(data)), listener)
+}
+
+// ParseApkFS parses the manifest of the apk whose files are in fsys, such
+// as a *zip.Reader or an unpacked apk.
+func ParseApkFS(fsys fs.FS, listener Listener) (*Parser, error) {
+	if r, ok := fsys.(*zip.Reader); ok {
+		return parseApkZip(r, listener)
+	}
+	bs, err := fs.ReadFile(fsys, "AndroidManifest.xml")
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNoManifest
+	}
+	if err != nil {
+		return nil, err
+	}
+	parser := New(listener)
+	err = parser.Parse(bs)
+	if err != nil {
+		return nil, err
 	}
+	return parser, nil
+}
+
+// zipEncryptedFlag marks the entries encrypted with a password
+const zipEncryptedFlag = 0x1
 
-	if xmlf == nil {
+func parseApkZip(r *zip.Reader, listener Listener) (*Parser, error) {
+	bs, err := readZipFile(r, "AndroidManifest.xml")
+	if err != nil {
 		return nil, err
 	}
+	if bs == nil {
+		return nil, ErrNoManifest
+	}
 
-	rc, err := xmlf.Open()
+	parser := New(listener)
+	err = parser.Parse(bs)
 	if err != nil {
 		return nil, err
 	}
-	defer rc.Close()
+	return parser, nil
+}
 
-	bs, err := ioutil.ReadAll(rc)
+// ParseApkWithResources parses the manifest of an apk like ParseApk, with
+// references in attribute values resolved against its resources.arsc. When
+// resources.arsc cannot be read, the manifest is parsed with references
+// left as they are and the reason is kept in the ResourcesErr field of the
+// returned Parser.
+func ParseApkWithResources(apkpath string, listener Listener) (*Parser, error) {
+	r, err := zip.OpenReader(apkpath)
 	if err != nil {
 		return nil, err
 	}
+	defer r.Close()
+
+	manifest, table, tableErr := readApk(&r.Reader)
+	if manifest == nil {
+		return nil, tableErr
+	}
 
 	parser := New(listener)
-	err = parser.Parse(bs)
+	parser.ResourcesErr = tableErr
+	if table != nil {
+		parser.Resolver = table
+	}
+	err = parser.Parse(manifest)
 	if err != nil {
 		return nil, err
 	}
 	return parser, nil
 }
 
+// readApk returns the manifest of the apk r and its resource table, nil
+// when the apk has no resources.arsc. The manifest is returned along with
+// the error when only the resource table cannot be read.
+func readApk(r *zip.Reader) ([]byte, *ResourceTable, error) {
+	manifest, err := readZipFile(r, "AndroidManifest.xml")
+	if err != nil {
+		return nil, nil, err
+	}
+	if manifest == nil {
+		return nil, nil, ErrNoManifest
+	}
+	resources, err := readZipFile(r, "resources.arsc")
+	if err == nil && resources == nil {
+		return manifest, nil, nil
+	}
+	var table *ResourceTable
+	if err == nil {
+		table, err = ParseResourceTable(resources)
+	}
+	if err != nil {
+		return manifest, nil, fmt.Errorf("axmlParser: resources.arsc: %w", err)
+	}
+	return manifest, table, nil
+}
+
+// readZipFile returns the content of the named file of r, or nil if r has
+// no such file.
+func readZipFile(r *zip.Reader, name string) ([]byte, error) {
+	for _, f := range r.File {
+		if f.Name == name {
+			return readZipEntry(f)
+		}
+	}
+	return nil, nil
+}
+
+// readZipEntry returns the content of f.
+func readZipEntry(f *zip.File) ([]byte, error) {
+	if f.Flags&zipEncryptedFlag != 0 {
+		return nil, fmt.Errorf("%w: %s", ErrEncryptedEntry, f.Name)
+	}
+	rc, err := f.Open()
+	if errors.Is(err, zip.ErrAlgorithm) {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedCompression, f.Name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	data, err := ioutil.ReadAll(rc)
+	if errors.Is(err, zip.ErrFormat) || errors.Is(err, io.ErrUnexpectedEOF) {
+		// while reading, the content was longer or shorter than the
+		// declared size
+		return nil, fmt.Errorf("%w: %s", ErrSizeMismatch, f.Name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
 func ParseAxml(axmlpath string, listener Listener) (*Parser, error) {
 	bs, err := ioutil.ReadFile(axmlpath)
 	if err != nil {
@@ -57,3 +177,17 @@ func ParseAxml(axmlpath string, listener Listener) (*Parser, error) {
 	}
 	return parser, nil
 }
+
+// ParseAxmlReader parses the binary XML document read from r.
+func ParseAxmlReader(r io.Reader, listener Listener) (*Parser, error) {
+	bs, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	parser := New(listener)
+	err = parser.Parse(bs)
+	if err != nil {
+		return nil, err
+	}
+	return parser, nil
+}
@@ -0,0 +1,146 @@
+package axmlParser
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseApkBytes(t *testing.T) {
+	apk := testApk(t, "classes.dex")
+
+	listener := new(AppNameListener)
+	if _, err := ParseApkBytes(apk, listener); err != nil {
+		t.Fatal(err)
+	}
+	if listener.PackageName != "com.example" || listener.ActivityName != "com.example.Main" {
+		t.Errorf("listener = %+v", listener)
+	}
+
+	r, err := zip.NewReader(bytes.NewReader(apk), int64(len(apk)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	listener = new(AppNameListener)
+	if _, err := ParseApkFS(r, listener); err != nil {
+		t.Fatal(err)
+	}
+	if listener.VersionCode != "12" {
+		t.Errorf("listener = %+v", listener)
+	}
+
+	manifest, err := readZipFile(r, "AndroidManifest.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	listener = new(AppNameListener)
+	if _, err := ParseAxmlReader(bytes.NewReader(manifest), listener); err != nil {
+		t.Fatal(err)
+	}
+	if listener.VersionName != "1.0" {
+		t.Errorf("listener = %+v", listener)
+	}
+
+	if _, err := ParseApkBytes([]byte("not a zip"), new(AppNameListener)); err == nil {
+		t.Error("ParseApkBytes succeeded on garbage")
+	}
+}
+
+func TestParseApkErrors(t *testing.T) {
+	// rawApk returns an apk holding data as its manifest, with the given
+	// header fields
+	rawApk := func(data []byte, fh zip.FileHeader) []byte {
+		var buf bytes.Buffer
+		w := zip.NewWriter(&buf)
+		fh.CRC32 = crc32.ChecksumIEEE(data)
+		fh.CompressedSize64 = uint64(len(data))
+		if fh.UncompressedSize64 == 0 {
+			fh.UncompressedSize64 = uint64(len(data))
+		}
+		fw, err := w.CreateRaw(&fh)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fw.Write(data)
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		return buf.Bytes()
+	}
+	manifest := []byte("binary xml")
+
+	tests := []struct {
+		apk []byte
+		err error
+	}{
+		{rawApk(manifest, zip.FileHeader{Name: "classes.dex"}), ErrNoManifest},
+		{rawApk(manifest, zip.FileHeader{Name: "AndroidManifest.xml", Flags: 0x1}), ErrEncryptedEntry},
+		{rawApk(manifest, zip.FileHeader{Name: "AndroidManifest.xml", Method: 99}), ErrUnsupportedCompression},
+		{rawApk(manifest, zip.FileHeader{Name: "AndroidManifest.xml", UncompressedSize64: 100}), ErrSizeMismatch},
+	}
+	for i, test := range tests {
+		if _, err := ParseApkBytes(test.apk, new(AppNameListener)); !errors.Is(err, test.err) {
+			t.Errorf("%d: ParseApkBytes error = %v, want %v", i, err, test.err)
+		}
+	}
+
+	for i, test := range tests[:2] {
+		r, err := zip.NewReader(bytes.NewReader(test.apk), int64(len(test.apk)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ParseApkFS(r, new(AppNameListener)); !errors.Is(err, test.err) {
+			t.Errorf("%d: ParseApkFS error = %v, want %v", i, err, test.err)
+		}
+	}
+
+	// a bad local header is not a size mismatch
+	apk := rawApk(manifest, zip.FileHeader{Name: "AndroidManifest.xml"})
+	apk[0] = 'X'
+	_, err := ParseApkBytes(apk, new(AppNameListener))
+	if !errors.Is(err, zip.ErrFormat) || errors.Is(err, ErrSizeMismatch) {
+		t.Errorf("bad local header: error = %v, want zip.ErrFormat", err)
+	}
+}
+
+func TestParseApkBadResources(t *testing.T) {
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for name, data := range map[string][]byte{
+		"AndroidManifest.xml": binaryXML(t, encoderManifest),
+		"resources.arsc":      []byte("garbage"),
+	} {
+		fw, err := w.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fw.Write(data)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	apk := filepath.Join(t.TempDir(), "bad.apk")
+	if err := os.WriteFile(apk, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	listener := new(AppNameListener)
+	parser, err := ParseApkWithResources(apk, listener)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !errors.Is(parser.ResourcesErr, ErrTruncated) || parser.Resolver != nil {
+		t.Errorf("ResourcesErr = %v, want ErrTruncated", parser.ResourcesErr)
+	}
+	if listener.PackageName != "com.example" || listener.VersionCode != "12" {
+		t.Errorf("listener = %+v", listener)
+	}
+
+	if _, err := ExtractIcon(apk, DENSITY_XXHIGH); !errors.Is(err, ErrTruncated) {
+		t.Errorf("ExtractIcon error = %v, want ErrTruncated", err)
+	}
+}
@@ -1,10 +1,12 @@
 package axmlParser
 
 import (
-	"bytes"
-	"encoding/binary"
 	"fmt"
+	"io"
+	"math"
 	"strconv"
+	"unicode/utf16"
+	"unicode/utf8"
 )
 
 const (
@@ -23,6 +25,31 @@ const (
 	WORD_EOS       = 0xFFFFFFFF
 	WORD_SIZE      = 4
 
+	// ResChunk_header types
+	RES_NULL_TYPE                = 0x0000
+	RES_STRING_POOL_TYPE         = 0x0001
+	RES_TABLE_TYPE               = 0x0002
+	RES_XML_TYPE                 = 0x0003
+	RES_XML_START_NAMESPACE_TYPE = 0x0100
+	RES_XML_END_NAMESPACE_TYPE   = 0x0101
+	RES_XML_START_ELEMENT_TYPE   = 0x0102
+	RES_XML_END_ELEMENT_TYPE     = 0x0103
+	RES_XML_CDATA_TYPE           = 0x0104
+	RES_XML_RESOURCE_MAP_TYPE    = 0x0180
+	RES_TABLE_PACKAGE_TYPE       = 0x0200
+	RES_TABLE_TYPE_TYPE          = 0x0201
+	RES_TABLE_TYPE_SPEC_TYPE     = 0x0202
+	RES_TABLE_LIBRARY_TYPE       = 0x0203
+
+	CHUNK_HEADER_SIZE = 8
+
+	// ResStringPool_header flags
+	SORTED_FLAG = 1 << 0
+	UTF8_FLAG   = 1 << 8
+
+	// ends a list of ResStringPool_span
+	SPAN_END = 0xFFFFFFFF
+
 	TYPE_ID_REF   = 0x01000008
 	TYPE_ATTR_REF = 0x02000008
 	TYPE_STRING   = 0x03000008
@@ -51,9 +78,22 @@ type Parser struct {
 	Data       []byte
 
 	StringsTable                        []string
+	StylesTable                         [][]Span
 	ResourcesIds                        []int
 	StringsCount, StylesCount, ResCount int
 	ParserOffset                        int
+
+	// Resolver, when set, replaces the values of references with the
+	// values they point to
+	Resolver Resolver
+	// ResourcesErr is why ParseApkWithResources could not use the
+	// resources.arsc of the apk, nil when it could or there was none
+	ResourcesErr error
+
+	// chunk is the type of the chunk being decoded, for errors
+	chunk int
+	// end is the offset where the document ends
+	end int
 }
 
 func New(listener Listener) *Parser {
@@ -62,42 +102,45 @@ func New(listener Listener) *Parser {
 		Namespaces:   make(map[string]string),
 		Data:         make([]byte, 0),
 		StringsTable: make([]string, 0),
+		StylesTable:  make([][]Span, 0),
 		ResourcesIds: make([]int, 0),
 	}
 }
 
 func (parser *Parser) IsValid(header []byte) bool {
+	if len(header) < WORD_SIZE {
+		return false
+	}
 	return (header[0] == 0x03) && (header[1] == 0x00) &&
 		(header[2] == 0x08) && (header[3] == 0x00)
 }
 
 func (parser *Parser) Parse(data []byte) error {
-	var word0 int64
 	parser.Data = data
+	parser.end = len(parser.Data)
 
-	for parser.ParserOffset < len(parser.Data) {
-		word0 = int64(parser.getLEWord(parser.ParserOffset))
-		switch word0 {
-		case WORD_START_DOCUMENT:
-			parser.parseStartDocument()
-		case WORD_STRING_TABLE:
-			parser.parseStringTable()
-		case WORD_RES_TABLE:
-			parser.parseResourceTable()
-		case WORD_START_NS:
-			parser.parseNamespace(true)
-		case WORD_END_NS:
-			parser.parseNamespace(false)
-		case WORD_START_TAG:
-			parser.parseStartTag()
-		case WORD_END_TAG:
-			parser.parseEndTag()
-		case WORD_TEXT:
-			parser.parseText()
-		case WORD_EOS:
-			parser.listener.EndDocument()
-		default:
-			parser.ParserOffset += WORD_SIZE
+	for {
+		token, err := parser.nextToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		switch t := token.(type) {
+		case startDocument:
+			parser.listener.StartDocument()
+		case StartNamespace:
+			parser.listener.StartPrefixMapping(t.Prefix, t.URI)
+		case EndNamespace:
+			parser.listener.EndPrefixMapping(t.Prefix, t.URI)
+		case StartElement:
+			parser.listener.StartElement(t.Namespace, t.Name, t.QName, t.Attrs)
+		case EndElement:
+			parser.listener.EndElement(t.Namespace, t.Name, t.QName)
+		case CharData:
+			parser.listener.CharacterData(t.Data)
 		}
 	}
 
@@ -105,150 +148,412 @@ func (parser *Parser) Parse(data []byte) error {
 	return nil
 }
 
+// nextToken decodes chunks until one of them yields a token, and returns
+// io.EOF once the document is exhausted.
+func (parser *Parser) nextToken() (Token, error) {
+	for parser.ParserOffset < parser.end {
+		parser.chunk = 0
+		header, err := parser.getChunkHeader(parser.ParserOffset, parser.end)
+		if err != nil {
+			return nil, err
+		}
+		parser.chunk = header.Type
+
+		var token Token
+		switch header.Type {
+		case RES_XML_TYPE:
+			// the document chunk wraps all the others
+			parser.end = header.Offset + header.Size
+			parser.ParserOffset += header.HeaderSize
+			return startDocument{}, nil
+		case RES_STRING_POOL_TYPE:
+			err = parser.parseStringTable(header)
+		case RES_XML_RESOURCE_MAP_TYPE:
+			err = parser.parseResourceTable(header)
+		case RES_XML_START_NAMESPACE_TYPE:
+			token, err = parser.parseNamespace(header, true)
+		case RES_XML_END_NAMESPACE_TYPE:
+			token, err = parser.parseNamespace(header, false)
+		case RES_XML_START_ELEMENT_TYPE:
+			token, err = parser.parseStartTag(header)
+		case RES_XML_END_ELEMENT_TYPE:
+			token, err = parser.parseEndTag(header)
+		case RES_XML_CDATA_TYPE:
+			token, err = parser.parseText(header)
+		default:
+			data := parser.Data[header.Offset : header.Offset+header.Size]
+			token = RawChunk{Type: header.Type, Data: append([]byte(nil), data...)}
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		// the next chunk starts past the declared size
+		parser.ParserOffset = header.Offset + header.Size
+		if token != nil {
+			return token, nil
+		}
+	}
+
+	return nil, io.EOF
+}
+
+// errorf builds a *ParseError for the chunk being decoded.
+func (parser *Parser) errorf(offset int, err error, format string, args ...interface{}) error {
+	return &ParseError{
+		Offset: offset,
+		Chunk:  parser.chunk,
+		Reason: fmt.Sprintf(format, args...),
+		Err:    err,
+	}
+}
+
+// chunkHeader is a decoded ResChunk_header, plus where it was found.
+type chunkHeader struct {
+	Offset     int
+	Type       int
+	HeaderSize int
+	Size       int
+}
+
 /**
- * A doc starts with the following 4bytes words :
+ * Every chunk starts with a ResChunk_header :
  * <ul>
- * <li>0th word : 0x00080003</li>
- * <li>1st word : chunk size</li>
+ * <li>uint16 : chunk type</li>
+ * <li>uint16 : header size, the offset from the chunk start to its data</li>
+ * <li>uint32 : chunk size, header included</li>
  * </ul>
+ *
+ * @param off
+ *            the offset of the chunk
+ * @param end
+ *            the offset the chunk must not extend past
  */
-func (parser *Parser) parseStartDocument() {
-	parser.listener.StartDocument()
-	parser.ParserOffset += (2 * WORD_SIZE)
+func (parser *Parser) getChunkHeader(off, end int) (chunkHeader, error) {
+	var header chunkHeader
+	typ, err := parser.getLEShort(off)
+	if err != nil {
+		return header, err
+	}
+	headerSize, err := parser.getLEShort(off + 2)
+	if err != nil {
+		return header, err
+	}
+	size, err := parser.getLEWord(off + WORD_SIZE)
+	if err != nil {
+		return header, err
+	}
+
+	header = chunkHeader{Offset: off, Type: typ, HeaderSize: headerSize, Size: size}
+	if headerSize < CHUNK_HEADER_SIZE || size < headerSize {
+		return header, parser.errorf(off, ErrBadChunk,
+			"chunk 0x%04X with header size %d and size %d", typ, headerSize, size)
+	}
+	if size > end-off {
+		return header, parser.errorf(off, ErrTruncated,
+			"chunk 0x%04X size %d exceeds data", typ, size)
+	}
+	return header, nil
+}
+
+// getLineNumber returns the line number of a ResXMLTree_node, or 0 if the
+// node header does not hold one.
+func (parser *Parser) getLineNumber(header chunkHeader) int {
+	if header.HeaderSize < 3*WORD_SIZE {
+		return 0
+	}
+	line, _ := parser.getLEWord(header.Offset + 2*WORD_SIZE)
+	return line
+}
+
+// getQualifiedName prefixes name with the prefix mapped to uri, if any.
+func (parser *Parser) getQualifiedName(uri, name string) string {
+	if v, ok := parser.Namespaces[uri]; ok && uri != "" {
+		return v + ":" + name
+	}
+	return name
+}
+
+// checkExtension makes sure a chunk holds extSize bytes past its header.
+func (parser *Parser) checkExtension(header chunkHeader, extSize int) error {
+	if header.Size-header.HeaderSize < extSize {
+		return parser.errorf(header.Offset, ErrBadChunk,
+			"chunk size %d too small for %d bytes of data", header.Size, extSize)
+	}
+	return nil
 }
 
 /**
- * the string table starts with the following 4bytes words :
+ * the string table starts with a ResStringPool_header :
  * <ul>
- * <li>0th word : 0x1c0001</li>
+ * <li>0th word : 0x0001 type, header size</li>
  * <li>1st word : chunk size</li>
  * <li>2nd word : number of string in the string table</li>
  * <li>3rd word : number of styles in the string table</li>
- * <li>4th word : ???? (0)</li>
+ * <li>4th word : flags</li>
  * <li>5th word : Offset to String data</li>
  * <li>6th word : Offset to style data</li>
  * </ul>
+ * The string offsets follow the header, then the style offsets.
  */
-func (parser *Parser) parseStringTable() {
-	chunk := parser.getLEWord(parser.ParserOffset + (1 * WORD_SIZE))
-	parser.StringsCount = parser.getLEWord(parser.ParserOffset + (2 * WORD_SIZE))
-	parser.StylesCount = parser.getLEWord(parser.ParserOffset + (3 * WORD_SIZE))
-	strOffset := parser.ParserOffset + parser.getLEWord(parser.ParserOffset+(5*WORD_SIZE))
-	styleOffset := parser.getLEWord(parser.ParserOffset + (6 * WORD_SIZE))
-
-	parser.StringsTable = make([]string, parser.StringsCount)
+func (parser *Parser) parseStringTable(header chunkHeader) error {
+	strs, styles, err := parser.readStringPool(header)
+	if err != nil {
+		return err
+	}
+
+	parser.StringsTable = strs
+	parser.StringsCount = len(strs)
+	parser.StylesTable = styles
+	parser.StylesCount = len(styles)
+	return nil
+}
+
+// readStringPool decodes the strings and styles of a string pool chunk.
+func (parser *Parser) readStringPool(header chunkHeader) ([]string, [][]Span, error) {
+	if header.HeaderSize < 7*WORD_SIZE {
+		return nil, nil, parser.errorf(header.Offset, ErrBadStringPool,
+			"header size %d too small", header.HeaderSize)
+	}
+	words, err := parser.getLEWords(header.Offset, 7)
+	if err != nil {
+		return nil, nil, err
+	}
+	stringsCount := words[2]
+	stylesCount := words[3]
+	isUTF8 := words[4]&UTF8_FLAG != 0
+	strOffset := header.Offset + words[5]
+	styleOffset := words[6]
+	indexOffset := header.Offset + header.HeaderSize
+
+	if stringsCount+stylesCount > (header.Size-header.HeaderSize)/WORD_SIZE {
+		return nil, nil, parser.errorf(header.Offset, ErrBadStringPool,
+			"%d strings do not fit in chunk of %d bytes", stringsCount, header.Size)
+	}
+	if words[5] > header.Size {
+		return nil, nil, parser.errorf(header.Offset, ErrBadStringPool,
+			"string data offset %d outside chunk", words[5])
+	}
+
+	strs := make([]string, stringsCount)
 	var offset int
-	for i := 0; i < int(parser.StringsCount); i++ {
-		offset = strOffset + parser.getLEWord(parser.ParserOffset+((i+7)*WORD_SIZE))
-		parser.StringsTable[i] = parser.getStringFromStringTable(offset)
+	for i := range strs {
+		offset, err = parser.getLEWord(indexOffset + (i * WORD_SIZE))
+		if err != nil {
+			return nil, nil, err
+		}
+		strs[i], err = parser.getStringFromStringTable(strOffset+offset, isUTF8)
+		if err != nil {
+			return nil, nil, err
+		}
 	}
 
+	styles := make([][]Span, stylesCount)
 	if styleOffset > 0 {
-		for i := 0; i < parser.StylesCount; i++ {
-			// TODO read the styles ???
+		if styleOffset > header.Size {
+			return nil, nil, parser.errorf(header.Offset, ErrBadStringPool,
+				"style data offset %d outside chunk", styleOffset)
+		}
+		indexOffset += stringsCount * WORD_SIZE
+		for i := range styles {
+			offset, err = parser.getLEWord(indexOffset + (i * WORD_SIZE))
+			if err != nil {
+				return nil, nil, err
+			}
+			styles[i], err = parser.getSpans(header, header.Offset+styleOffset+offset, strs)
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+
+	return strs, styles, nil
+}
+
+/**
+ * A style is a list of ResStringPool_span, each made of the following
+ * 4bytes words :
+ * <ul>
+ * <li>0th word : index of the span tag name in the string pool</li>
+ * <li>1st word : first styled character</li>
+ * <li>2nd word : last styled character</li>
+ * </ul>
+ * The list ends with a 0xFFFFFFFF word.
+ */
+func (parser *Parser) getSpans(header chunkHeader, offset int, strs []string) ([]Span, error) {
+	end := header.Offset + header.Size
+	spans := make([]Span, 0)
+	for {
+		if offset < 0 || offset+WORD_SIZE > end {
+			return nil, parser.errorf(offset, ErrBadStringPool, "unterminated style")
+		}
+		nameIdx, _ := parser.getLEWord(offset)
+		if int64(nameIdx) == SPAN_END {
+			return spans, nil
+		}
+		if offset+3*WORD_SIZE > end {
+			return nil, parser.errorf(offset, ErrBadStringPool, "unterminated style")
+		}
+		words, err := parser.getLEWords(offset, 3)
+		if err != nil {
+			return nil, err
+		}
+		name := ""
+		if words[0] < len(strs) {
+			name = strs[words[0]]
 		}
+		spans = append(spans, Span{
+			Name:      name,
+			FirstChar: words[1],
+			LastChar:  words[2],
+		})
+		offset += 3 * WORD_SIZE
 	}
+}
 
-	parser.ParserOffset += chunk
+// StringStyle returns the style spans of the string at index in the string
+// pool, or nil when the string is not styled.
+func (parser *Parser) StringStyle(index int) []Span {
+	if index < 0 || index >= len(parser.StylesTable) {
+		return nil
+	}
+	return parser.StylesTable[index]
 }
 
 /**
  * the resource ids table starts with the following 4bytes words :
  * <ul>
- * <li>0th word : 0x00080180</li>
+ * <li>0th word : 0x0180 type, header size</li>
  * <li>1st word : chunk size</li>
  * </ul>
+ * The resource ids fill the rest of the chunk.
  */
-func (parser *Parser) parseResourceTable() {
-	chunk := parser.getLEWord(parser.ParserOffset + (1 * WORD_SIZE))
-	parser.ResCount = (chunk / 4) - 2
+func (parser *Parser) parseResourceTable(header chunkHeader) error {
+	parser.ResCount = (header.Size - header.HeaderSize) / WORD_SIZE
 
+	var err error
 	parser.ResourcesIds = make([]int, parser.ResCount)
 	for i := 0; i < parser.ResCount; i++ {
-		parser.ResourcesIds[i] = parser.getLEWord(parser.ParserOffset + ((i + 2) * WORD_SIZE))
+		parser.ResourcesIds[i], err = parser.getLEWord(header.Offset + header.HeaderSize + (i * WORD_SIZE))
+		if err != nil {
+			return err
+		}
 	}
 
-	parser.ParserOffset += chunk
+	return nil
 }
 
 /**
  * A namespace tag contains the following 4bytes words :
  * <ul>
- * <li>0th word : 0x00100100 = Start NS / 0x00100101 = end NS</li>
+ * <li>0th word : 0x0100 = Start NS / 0x0101 = end NS, header size</li>
  * <li>1st word : chunk size</li>
  * <li>2nd word : line this tag appeared</li>
  * <li>3rd word : ??? (always 0xFFFFFF)</li>
- * <li>4th word : index of namespace prefix in StringIndexTable</li>
- * <li>5th word : index of namespace uri in StringIndexTable</li>
+ * </ul>
+ * followed, after the header, by :
+ * <ul>
+ * <li>0th word : index of namespace prefix in StringIndexTable</li>
+ * <li>1st word : index of namespace uri in StringIndexTable</li>
  * </ul>
  */
-func (parser *Parser) parseNamespace(start bool) {
-	prefixIdx := parser.getLEWord(parser.ParserOffset + (4 * WORD_SIZE))
-	uriIdx := parser.getLEWord(parser.ParserOffset + (5 * WORD_SIZE))
+func (parser *Parser) parseNamespace(header chunkHeader, start bool) (Token, error) {
+	if err := parser.checkExtension(header, 2*WORD_SIZE); err != nil {
+		return nil, err
+	}
+	words, err := parser.getLEWords(header.Offset+header.HeaderSize, 2)
+	if err != nil {
+		return nil, err
+	}
+	prefixIdx := words[0]
+	uriIdx := words[1]
 
 	uri := parser.getString(uriIdx)
 	prefix := parser.getString(prefixIdx)
 
+	line := parser.getLineNumber(header)
+
 	if start {
-		parser.listener.StartPrefixMapping(prefix, uri)
 		parser.Namespaces[uri] = prefix
-	} else {
-		parser.listener.EndPrefixMapping(prefix, uri)
-		delete(parser.Namespaces, uri)
+		return StartNamespace{Prefix: prefix, URI: uri, Line: line}, nil
 	}
-
-	// Offset to first tag
-	parser.ParserOffset += (6 * WORD_SIZE)
+	delete(parser.Namespaces, uri)
+	return EndNamespace{Prefix: prefix, URI: uri, Line: line}, nil
 }
 
 /**
  * A start tag will start with the following 4bytes words :
  * <ul>
- * <li>0th word : 0x00100102 = Start_Tag</li>
+ * <li>0th word : 0x0102 = Start_Tag, header size</li>
  * <li>1st word : chunk size</li>
  * <li>2nd word : line this tag appeared in the original file</li>
  * <li>3rd word : ??? (always 0xFFFFFF)</li>
- * <li>4th word : index of namespace uri in StringIndexTable, or 0xFFFFFFFF
+ * </ul>
+ * followed, after the header, by a ResXMLTree_attrExt :
+ * <ul>
+ * <li>0th word : index of namespace uri in StringIndexTable, or 0xFFFFFFFF
  * for default NS</li>
- * <li>5th word : index of element name in StringIndexTable</li>
- * <li>6th word : ???</li>
- * <li>7th word : number of attributes following the start tag</li>
- * <li>8th word : ??? (0)</li>
+ * <li>1st word : index of element name in StringIndexTable</li>
+ * <li>2nd word : uint16 offset of the first attribute from the start of
+ * this extension, uint16 size of each attribute</li>
+ * <li>3rd word : uint16 number of attributes, uint16 index of the id
+ * attribute</li>
+ * <li>4th word : uint16 index of the class and style attributes</li>
  * </ul>
  *
  */
-func (parser *Parser) parseStartTag() {
+func (parser *Parser) parseStartTag(header chunkHeader) (Token, error) {
+	if err := parser.checkExtension(header, 5*WORD_SIZE); err != nil {
+		return nil, err
+	}
+
 	// get tag info
-	uriIdx := parser.getLEWord(parser.ParserOffset + (4 * WORD_SIZE))
-	nameIdx := parser.getLEWord(parser.ParserOffset + (5 * WORD_SIZE))
-	attrCount := parser.getLEWord(parser.ParserOffset + (7 * WORD_SIZE))
+	ext := header.Offset + header.HeaderSize
+	words, err := parser.getLEWords(ext, 4)
+	if err != nil {
+		return nil, err
+	}
+	uriIdx := words[0]
+	nameIdx := words[1]
+	attrStart := words[2] & 0xFFFF
+	attrSize := (words[2] >> 16) & 0xFFFF
+	attrCount := words[3] & 0xFFFF
+
+	if attrCount > 0 {
+		if attrSize < 5*WORD_SIZE {
+			return nil, parser.errorf(header.Offset, ErrBadChunk,
+				"attribute size %d too small", attrSize)
+		}
+		if ext+attrStart+(attrCount-1)*attrSize+5*WORD_SIZE > header.Offset+header.Size {
+			return nil, parser.errorf(header.Offset, ErrTruncated,
+				"%d attributes exceed chunk", attrCount)
+		}
+	}
 
 	name := parser.getString(nameIdx)
-	var uri, qname string
+	var uri string
 	if int64(uriIdx) == 0xFFFFFFFF {
 		uri = ""
-		qname = name
 	} else {
 		uri = parser.getString(uriIdx)
-		if v, ok := parser.Namespaces[uri]; ok {
-			qname = v + ":" + name
-		} else {
-			qname = name
-		}
 	}
 
-	// offset to start of attributes
-	parser.ParserOffset += (9 * WORD_SIZE)
-
 	attrs := make([]*Attribute, attrCount) // NOPMD
 	for a := 0; a < attrCount; a++ {
-		attrs[a] = parser.parseAttribute() // NOPMD
-
-		// offset to next attribute or tag
-		parser.ParserOffset += (5 * 4)
+		// attributes are laid out by their declared stride
+		attrs[a], err = parser.parseAttribute(ext + attrStart + a*attrSize) // NOPMD
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	parser.listener.StartElement(uri, name, qname, attrs)
+	return StartElement{
+		Namespace: uri,
+		Name:      name,
+		QName:     parser.getQualifiedName(uri, name),
+		Attrs:     attrs,
+		Line:      parser.getLineNumber(header),
+	}, nil
 }
 
 /**
@@ -259,19 +564,40 @@ func (parser *Parser) parseStartTag() {
  * <li>1st word : index of attribute name in StringIndexTable</li>
  * <li>2nd word : index of attribute value, or 0xFFFFFFFF if value is a
  * typed value</li>
- * <li>3rd word : value type</li>
- * <li>4th word : resource id value</li>
+ * <li>3rd word : value type, a Res_value made of a uint16 size, a 0 byte and
+ * the data type byte</li>
+ * <li>4th word : value data, such as a resource id</li>
  * </ul>
  */
-func (parser *Parser) parseAttribute() *Attribute {
-	attrNSIdx := parser.getLEWord(parser.ParserOffset)
-	attrNameIdx := parser.getLEWord(parser.ParserOffset + (1 * WORD_SIZE))
-	attrValueIdx := parser.getLEWord(parser.ParserOffset + (2 * WORD_SIZE))
-	attrType := parser.getLEWord(parser.ParserOffset + (3 * WORD_SIZE))
-	attrData := parser.getLEWord(parser.ParserOffset + (4 * WORD_SIZE))
+func (parser *Parser) parseAttribute(offset int) (*Attribute, error) {
+	words, err := parser.getLEWords(offset, 5)
+	if err != nil {
+		return nil, err
+	}
+	attrNSIdx := words[0]
+	attrNameIdx := words[1]
+	attrValueIdx := words[2]
+	attrType := words[3]
+	attrData := words[4]
 
 	attr := new(Attribute)
-	attr.Name = parser.getString(attrNameIdx)
+	attr.RawName = parser.getString(attrNameIdx)
+	attr.Name = attr.RawName
+	attr.Type = (attrType >> 24) & 0xFF
+	attr.Data = attrData
+	attr.StringIndex = -1
+	if int64(attrValueIdx) != 0xFFFFFFFF {
+		attr.StringIndex = attrValueIdx
+	}
+	if attrNameIdx < len(parser.ResourcesIds) {
+		attr.NameResourceID = parser.ResourcesIds[attrNameIdx]
+
+		// Android matches attributes by resource ID, so trust it over
+		// name strings that may have been stripped or scrambled
+		if name, ok := AndroidAttrName(attr.NameResourceID); ok {
+			attr.Name = name
+		}
+	}
 
 	if int64(attrNSIdx) == 0xFFFFFFFF {
 		attr.Namespace = ""
@@ -285,54 +611,79 @@ func (parser *Parser) parseAttribute() *Attribute {
 	}
 
 	if int64(attrValueIdx) == 0xFFFFFFFF {
-		attr.Value = parser.getAttributeValue(attrType, attrData)
+		attr.Value = parser.getAttributeValue(attr.Type, attr.Data)
 	} else {
 		attr.Value = parser.getString(attrValueIdx)
 	}
 
-	return attr
+	// the reference stays in Type and Data, only Value is resolved
+	if parser.Resolver != nil && attr.IsReference() && attr.Data != 0 {
+		if value, err := parser.Resolver.ResolveReference(attr.Data); err == nil {
+			attr.Value = value
+		}
+	}
+
+	return attr, nil
 }
 
 /**
  * A text will start with the following 4bytes word :
  * <ul>
- * <li>0th word : 0x00100104 = Text</li>
+ * <li>0th word : 0x0104 = Text, header size</li>
  * <li>1st word : chunk size</li>
  * <li>2nd word : line this element appeared in the original document</li>
  * <li>3rd word : ??? (always 0xFFFFFFFF)</li>
- * <li>4rd word : string index in string table</li>
- * <li>5rd word : ??? (always 8)</li>
- * <li>6rd word : ??? (always 0)</li>
+ * </ul>
+ * followed, after the header, by :
+ * <ul>
+ * <li>0th word : string index in string table</li>
+ * <li>1st word : ??? (always 8)</li>
+ * <li>2nd word : ??? (always 0)</li>
  * </ul>
  *
  */
-func (parser *Parser) parseText() {
+func (parser *Parser) parseText(header chunkHeader) (Token, error) {
+	if err := parser.checkExtension(header, WORD_SIZE); err != nil {
+		return nil, err
+	}
+
 	// get tag infos
-	strIndex := parser.getLEWord(parser.ParserOffset + (4 * WORD_SIZE))
+	strIndex, err := parser.getLEWord(header.Offset + header.HeaderSize)
+	if err != nil {
+		return nil, err
+	}
 
 	data := parser.getString(strIndex)
-	parser.listener.CharacterData(data)
-
-	// offset to next node
-	parser.ParserOffset += (7 * WORD_SIZE)
+	return CharData{Data: data, Line: parser.getLineNumber(header)}, nil
 }
 
 /**
  * EndTag contains the following 4bytes words :
  * <ul>
- * <li>0th word : 0x00100103 = End_Tag</li>
+ * <li>0th word : 0x0103 = End_Tag, header size</li>
  * <li>1st word : chunk size</li>
  * <li>2nd word : line this tag appeared in the original file</li>
  * <li>3rd word : ??? (always 0xFFFFFFFF)</li>
- * <li>4th word : index of namespace name in StringIndexTable, or 0xFFFFFFFF
+ * </ul>
+ * followed, after the header, by :
+ * <ul>
+ * <li>0th word : index of namespace name in StringIndexTable, or 0xFFFFFFFF
  * for default NS</li>
- * <li>5th word : index of element name in StringIndexTable</li>
+ * <li>1st word : index of element name in StringIndexTable</li>
  * </ul>
  */
-func (parser *Parser) parseEndTag() {
+func (parser *Parser) parseEndTag(header chunkHeader) (Token, error) {
+	if err := parser.checkExtension(header, 2*WORD_SIZE); err != nil {
+		return nil, err
+	}
+
 	// get tag info
-	uriIdx := parser.getLEWord(parser.ParserOffset + (4 * WORD_SIZE))
-	nameIdx := parser.getLEWord(parser.ParserOffset + (5 * WORD_SIZE))
+	words, err := parser.getLEWords(header.Offset+header.HeaderSize, 2)
+	if err != nil {
+		return nil, err
+	}
+	uriIdx := words[0]
+	nameIdx := words[1]
 
 	name := parser.getString(nameIdx)
 	var uri string
@@ -342,10 +693,12 @@ func (parser *Parser) parseEndTag() {
 		uri = parser.getString(uriIdx)
 	}
 
-	parser.listener.EndElement(uri, name, "")
-
-	// offset to start of next tag
-	parser.ParserOffset += (6 * WORD_SIZE)
+	return EndElement{
+		Namespace: uri,
+		Name:      name,
+		QName:     parser.getQualifiedName(uri, name),
+		Line:      parser.getLineNumber(header),
+	}, nil
 }
 
 /**
@@ -368,26 +721,123 @@ func (parser *Parser) getString(index int) string {
  * @param offset
  *            offset of the beginning of the string inside the StringTable
  *            (and not the whole data array)
+ * @param isUTF8
+ *            whether the string pool has the UTF8_FLAG set
  * @return the String
  */
-func (parser *Parser) getStringFromStringTable(offset int) string {
-	var strLength int
-	var chars []byte
-	if parser.Data[offset+1] == parser.Data[offset] {
-		strLength = int(parser.Data[offset])
-		chars = make([]byte, strLength) // NOPMD
-		for i := 0; i < strLength; i++ {
-			chars[i] = parser.Data[offset+2+i] // NOPMD
+func (parser *Parser) getStringFromStringTable(offset int, isUTF8 bool) (string, error) {
+	if isUTF8 {
+		return parser.getUTF8String(offset)
+	}
+	return parser.getUTF16String(offset)
+}
+
+/**
+ * A UTF-8 string is stored as :
+ * <ul>
+ * <li>its length in UTF-16 units, on one byte, or two bytes when the high
+ * bit of the first one is set</li>
+ * <li>its length in bytes, encoded the same way</li>
+ * <li>the UTF-8 bytes, followed by a 0 byte</li>
+ * </ul>
+ */
+func (parser *Parser) getUTF8String(offset int) (string, error) {
+	_, offset, err := parser.getUTF8Length(offset)
+	if err != nil {
+		return "", err
+	}
+	strLength, offset, err := parser.getUTF8Length(offset)
+	if err != nil {
+		return "", err
+	}
+	if strLength > len(parser.Data)-offset {
+		return "", parser.errorf(offset, ErrBadStringPool,
+			"string of length %d exceeds data", strLength)
+	}
+	return decodeModifiedUTF8(parser.Data[offset : offset+strLength]), nil
+}
+
+// getUTF8Length reads a UTF-8 pool length prefix and returns it along with
+// the offset following it.
+func (parser *Parser) getUTF8Length(offset int) (int, int, error) {
+	if offset < 0 || offset >= len(parser.Data) {
+		return 0, 0, parser.errorf(offset, ErrBadStringPool, "string offset out of range")
+	}
+	length := int(parser.Data[offset])
+	if length&0x80 == 0 {
+		return length, offset + 1, nil
+	}
+	if offset+1 >= len(parser.Data) {
+		return 0, 0, parser.errorf(offset, ErrBadStringPool, "string offset out of range")
+	}
+	return (length&0x7F)<<8 | int(parser.Data[offset+1]), offset + 2, nil
+}
+
+/**
+ * A UTF-16 string is stored as :
+ * <ul>
+ * <li>its length in UTF-16 units, on one short, or two shorts when the
+ * high bit of the first one is set</li>
+ * <li>the UTF-16LE units, followed by a 0 unit</li>
+ * </ul>
+ */
+func (parser *Parser) getUTF16String(offset int) (string, error) {
+	strLength, err := parser.getLEShort(offset)
+	if err != nil {
+		return "", parser.errorf(offset, ErrBadStringPool, "string offset out of range")
+	}
+	offset += 2
+	if strLength&0x8000 != 0 {
+		low, err := parser.getLEShort(offset)
+		if err != nil {
+			return "", parser.errorf(offset, ErrBadStringPool, "string offset out of range")
 		}
-	} else {
-		strLength = ((int(parser.Data[offset+1] << 8)) & 0xFF00) |
-			(int(parser.Data[offset]) & 0xFF)
-		chars = make([]byte, strLength) // NOPMD
-		for i := 0; i < strLength; i++ {
-			chars[i] = parser.Data[offset+2+(i*2)] // NOPMD
+		strLength = (strLength&0x7FFF)<<16 | low
+		offset += 2
+	}
+	if strLength > (len(parser.Data)-offset)/2 {
+		return "", parser.errorf(offset, ErrBadStringPool,
+			"string of length %d exceeds data", strLength)
+	}
+
+	chars := make([]uint16, strLength) // NOPMD
+	for i := range chars {
+		chars[i] = uint16(parser.Data[offset+2*i]) | uint16(parser.Data[offset+2*i+1])<<8
+	}
+	return string(utf16.Decode(chars)), nil
+}
+
+// decodeModifiedUTF8 decodes UTF-8 as written by aapt, which may encode
+// characters outside the BMP as CESU-8 surrogate pairs.
+func decodeModifiedUTF8(b []byte) string {
+	if utf8.Valid(b) {
+		return string(b)
+	}
+
+	runes := make([]rune, 0, len(b))
+	for len(b) > 0 {
+		if r, ok := decodeSurrogatePair(b); ok {
+			runes = append(runes, r)
+			b = b[6:]
+			continue
 		}
+		r, size := utf8.DecodeRune(b)
+		runes = append(runes, r)
+		b = b[size:]
+	}
+	return string(runes)
+}
+
+// decodeSurrogatePair decodes a high and low surrogate, each written as a
+// three byte UTF-8 sequence.
+func decodeSurrogatePair(b []byte) (rune, bool) {
+	if len(b) < 6 || b[0] != 0xED || b[3] != 0xED ||
+		b[1]&0xF0 != 0xA0 || b[4]&0xF0 != 0xB0 {
+		return 0, false
 	}
-	return string(chars)
+	high := rune(b[1]&0x0F)<<6 | rune(b[2]&0x3F) | 0xD800
+	low := rune(b[4]&0x0F)<<6 | rune(b[5]&0x3F) | 0xDC00
+	return utf16.DecodeRune(high, low), true
 }
 
 /**
@@ -398,65 +848,101 @@ func (parser *Parser) getStringFromStringTable(offset int) string {
  * @return value of a Little Endian 32 bit word from the byte arrayat offset
  *         off.
  */
-func (parser *Parser) getLEWord(off int) int {
+func (parser *Parser) getLEWord(off int) (int, error) {
+	if off < 0 || off > len(parser.Data)-WORD_SIZE {
+		return 0, parser.errorf(off, ErrTruncated, "reading word")
+	}
 	return int(int((int64(parser.Data[off+3])<<24)&0xff000000) |
 		((int(parser.Data[off+2]) << 16) & 0x00ff0000) |
 		((int(parser.Data[off+1]) << 8) & 0x0000ff00) |
-		((int(parser.Data[off+0]) << 0) & 0x000000ff))
+		((int(parser.Data[off+0]) << 0) & 0x000000ff)), nil
+}
+
+/**
+ * @param off
+ *            the offset of the short to read
+ * @return value of a Little Endian 16 bit short from the data at offset off.
+ */
+func (parser *Parser) getLEShort(off int) (int, error) {
+	if off < 0 || off > len(parser.Data)-2 {
+		return 0, parser.errorf(off, ErrTruncated, "reading short")
+	}
+	return int(parser.Data[off]) | int(parser.Data[off+1])<<8, nil
+}
+
+/**
+ * @param off
+ *            the offset of the first word to read
+ * @param count
+ *            the number of consecutive words to read
+ * @return the words, or an error if any of them lies past the end of data
+ */
+func (parser *Parser) getLEWords(off, count int) ([]int, error) {
+	if off < 0 || off > len(parser.Data)-count*WORD_SIZE {
+		return nil, parser.errorf(off, ErrTruncated, "reading %d words", count)
+	}
+	words := make([]int, count)
+	for i := range words {
+		words[i], _ = parser.getLEWord(off + i*WORD_SIZE)
+	}
+	return words, nil
 }
 
 /**
- * @param word
- *            a word read in an attribute data
- * @return the typed value
+ * @param tpe
+ *            the Res_value data type, one of RES_TYPE_*
+ * @param data
+ *            the Res_value data word
+ * @return the typed value, formatted the way aapt2 dump xmltree does
  */
 func (parser *Parser) getAttributeValue(tpe int, data int) string {
 	var res string
 
 	switch tpe {
-	case TYPE_STRING:
-		res = parser.getString(data)
-	case TYPE_DIMEN:
-		res = fmt.Sprintf("%v", data>>8) + DIMEN[data&0xFF]
-	case TYPE_FRACTION:
-		fracValue := (float64(data) / (float64(0x7FFFFFFF)))
-		// res = String.format("%.2f%%", fracValue);
-		//res = new DecimalFormat("#.##%").format(fracValue)
-		res = fmt.Sprintf("%.2f%%", fracValue)
-	case TYPE_FLOAT:
-		buf := new(bytes.Buffer)
-		err := binary.Write(buf, binary.LittleEndian, int32(data))
-		if err != nil {
-			panic(err)
+	case RES_TYPE_NULL:
+		if data == DATA_NULL_EMPTY {
+			res = "@empty"
+		} else {
+			res = "@null"
 		}
-		var fl float32
-		err = binary.Read(buf, binary.LittleEndian, &fl)
-		if err != nil {
-			panic(err)
+	case RES_TYPE_REFERENCE, RES_TYPE_DYNAMIC_REFERENCE:
+		if data == 0 {
+			res = "@null"
+		} else {
+			res = fmt.Sprintf("@0x%08x", data)
 		}
-		//res = Float.toString(Float.intBitsToFloat(data))
-		res = fmt.Sprintf("%f", fl)
-	case TYPE_INT:
-		fallthrough
-	case TYPE_FLAGS:
-		res = strconv.Itoa(data)
-	case TYPE_BOOL:
-		//res = Boolean.toString(data != 0)
+	case RES_TYPE_ATTRIBUTE, RES_TYPE_DYNAMIC_ATTRIBUTE:
+		res = fmt.Sprintf("?0x%08x", data)
+	case RES_TYPE_STRING:
+		res = parser.getString(data)
+	case RES_TYPE_FLOAT:
+		res = formatFloat(math.Float32frombits(uint32(data)))
+	case RES_TYPE_DIMENSION:
+		res = formatDimension(data)
+	case RES_TYPE_FRACTION:
+		res = formatFraction(data)
+	case RES_TYPE_INT_DEC:
+		res = strconv.Itoa(int(int32(data)))
+	case RES_TYPE_INT_HEX:
+		res = fmt.Sprintf("0x%08x", data)
+	case RES_TYPE_INT_BOOLEAN:
 		if data != 0 {
 			res = "true"
 		} else {
 			res = "false"
 		}
-	case TYPE_COLOR:
-		fallthrough
-	case TYPE_COLOR2:
-		res = fmt.Sprintf("%#08X", data)
-	case TYPE_ID_REF:
-		res = fmt.Sprintf("@id/0x%08X", data)
-	case TYPE_ATTR_REF:
-		res = fmt.Sprintf("?id/0x%08X", data)
+	case RES_TYPE_INT_COLOR_ARGB8:
+		res = fmt.Sprintf("#%08x", data)
+	case RES_TYPE_INT_COLOR_RGB8:
+		res = fmt.Sprintf("#%06x", data&0xFFFFFF)
+	case RES_TYPE_INT_COLOR_ARGB4:
+		// each nibble is stored twice, as in 0xAARRGGBB
+		res = fmt.Sprintf("#%x%x%x%x", (data>>28)&0xF, (data>>20)&0xF,
+			(data>>12)&0xF, (data>>4)&0xF)
+	case RES_TYPE_INT_COLOR_RGB4:
+		res = fmt.Sprintf("#%x%x%x", (data>>20)&0xF, (data>>12)&0xF, (data>>4)&0xF)
 	default:
-		res = fmt.Sprintf("%08X/0x%08X", tpe, data)
+		res = fmt.Sprintf("(unknown 0x%02x) 0x%08x", tpe, data)
 	}
 
 	return res
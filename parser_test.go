@@ -1,8 +1,12 @@
 package axmlParser
 
 import (
+	"bytes"
+	"encoding/binary"
+	"errors"
 	"fmt"
 	"testing"
+	"unicode/utf16"
 )
 
 func TestParser(t *testing.T) {
@@ -17,3 +21,274 @@ func TestParser(t *testing.T) {
 	fmt.Println("Init package is", listener.PackageName,
 		"Activity is", listener.ActivityName)
 }
+
+// testManifest returns a minimal binary manifest equivalent to
+// <manifest xmlns:android="..." package="com.example" android:versionCode="7"/>
+func testManifest() []byte {
+	var body []byte
+	body = append(body, testStringPool("versionCode", "android", ANDROID_NAMESPACE,
+		"manifest", "package", "com.example")...)
+	body = append(body, le32(0x00080180, 12, 0x0101021b)...)
+	body = append(body, le32(0x00100100, 24, 1, 0xFFFFFFFF, 1, 2)...)
+	body = append(body, le32(0x00100102, 36+40, 1, 0xFFFFFFFF, 0xFFFFFFFF, 3, 0x00140014, 2, 0)...)
+	body = append(body, le32(0xFFFFFFFF, 4, 5, 0x03000008, 5)...)
+	body = append(body, le32(2, 0, 0xFFFFFFFF, 0x10000008, 7)...)
+	body = append(body, le32(0x00100103, 24, 1, 0xFFFFFFFF, 0xFFFFFFFF, 3)...)
+	body = append(body, le32(0x00100101, 24, 1, 0xFFFFFFFF, 1, 2)...)
+
+	return append(le32(0x00080003, uint32(8+len(body))), body...)
+}
+
+// le32 encodes v as little-endian words.
+func le32(v ...uint32) []byte {
+	b := make([]byte, 4*len(v))
+	for i, w := range v {
+		binary.LittleEndian.PutUint32(b[i*4:], w)
+	}
+	return b
+}
+
+// testStringPool encodes strs as a UTF-16 string pool chunk.
+func testStringPool(strs ...string) []byte {
+	return testStyledStringPool(strs, nil)
+}
+
+// testStyledStringPool encodes strs as a UTF-16 string pool chunk, the
+// first strings being styled by styles. Span names must be in strs.
+func testStyledStringPool(strs []string, styles [][]Span) []byte {
+	var pool, offsets []byte
+	for _, s := range strs {
+		offsets = append(offsets, le32(uint32(len(pool)))...)
+		units := utf16.Encode([]rune(s))
+		pool = append(pool, byte(len(units)), 0)
+		for _, u := range units {
+			pool = append(pool, byte(u), byte(u>>8))
+		}
+		pool = append(pool, 0, 0)
+	}
+	for len(pool)%4 != 0 {
+		pool = append(pool, 0)
+	}
+
+	var styleData []byte
+	for _, spans := range styles {
+		offsets = append(offsets, le32(uint32(len(styleData)))...)
+		for _, span := range spans {
+			name := 0
+			for i, s := range strs {
+				if s == span.Name {
+					name = i
+				}
+			}
+			styleData = append(styleData, le32(uint32(name), uint32(span.FirstChar), uint32(span.LastChar))...)
+		}
+		styleData = append(styleData, le32(SPAN_END)...)
+	}
+	var styleStart int
+	if len(styles) > 0 {
+		// aapt ends the style data with two more terminators
+		styleData = append(styleData, le32(SPAN_END, SPAN_END)...)
+		styleStart = 28 + len(offsets) + len(pool)
+	}
+
+	chunk := append(le32(0x001C0001, uint32(28+len(offsets)+len(pool)+len(styleData)),
+		uint32(len(strs)), uint32(len(styles)), 0, uint32(28+len(offsets)), uint32(styleStart)), offsets...)
+	chunk = append(chunk, pool...)
+	return append(chunk, styleData...)
+}
+
+func TestParseManifest(t *testing.T) {
+	listener := new(AppNameListener)
+	parser := New(listener)
+	if err := parser.Parse(testManifest()); err != nil {
+		t.Fatal(err)
+	}
+	if listener.PackageName != "com.example" {
+		t.Errorf("PackageName = %q", listener.PackageName)
+	}
+	if listener.VersionCode != "7" {
+		t.Errorf("VersionCode = %q", listener.VersionCode)
+	}
+}
+
+func TestAttributeLayout(t *testing.T) {
+	// the start tag header has 8 extra bytes, and its attributes start 4
+	// bytes past the extension with a stride of 24 bytes
+	var body []byte
+	body = append(body, testStringPool("versionCode", "android", ANDROID_NAMESPACE,
+		"manifest", "package", "com.example")...)
+	body = append(body, le32(0x00080180, 12, 0x0101021b)...)
+	body = append(body, le32(0x00100100, 24, 1, 0xFFFFFFFF, 1, 2)...)
+	body = append(body, le32(0x00180102, 24+24+2*24, 1, 0xFFFFFFFF, 0xCAFEBABE, 0xCAFEBABE)...)
+	body = append(body, le32(0xFFFFFFFF, 3, 0x00180018, 2, 0, 0xCAFEBABE)...)
+	body = append(body, le32(0xFFFFFFFF, 4, 5, 0x03000008, 5, 0xCAFEBABE)...)
+	body = append(body, le32(2, 0, 0xFFFFFFFF, 0x10000008, 7, 0xCAFEBABE)...)
+	body = append(body, le32(0x00100103, 24, 1, 0xFFFFFFFF, 0xFFFFFFFF, 3)...)
+	body = append(body, le32(0x00100101, 24, 1, 0xFFFFFFFF, 1, 2)...)
+	data := append(le32(0x00080003, uint32(8+len(body))), body...)
+
+	doc, err := ParseDocument(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.Root == nil || doc.Root.Name != "manifest" || len(doc.Root.Attrs) != 2 {
+		t.Fatalf("root = %+v", doc.Root)
+	}
+	if pkg := doc.Root.AttrValue("", "package"); pkg != "com.example" {
+		t.Errorf("package = %q", pkg)
+	}
+	code := doc.Root.Attr(ANDROID_NAMESPACE, "versionCode")
+	if code == nil || code.Type != RES_TYPE_INT_DEC || code.Data != 7 || code.Prefix != "android" {
+		t.Errorf("versionCode = %+v", code)
+	}
+}
+
+func TestStringStyles(t *testing.T) {
+	strs := []string{"Hello bold world", "plain", "b", "i", "a;href=http://example.com"}
+	styles := [][]Span{
+		{{Name: "b", FirstChar: 6, LastChar: 15}, {Name: "i", FirstChar: 11, LastChar: 15},
+			{Name: "a;href=http://example.com", FirstChar: 0, LastChar: 4}},
+		{},
+	}
+	pool := testStyledStringPool(strs, styles)
+	data := append(le32(0x00080003, uint32(8+len(pool))), pool...)
+
+	parser := New(new(TreeListener))
+	if err := parser.Parse(data); err != nil {
+		t.Fatal(err)
+	}
+	if len(parser.StringsTable) != len(strs) || parser.StylesCount != 2 {
+		t.Fatalf("%d strings, %d styles", len(parser.StringsTable), parser.StylesCount)
+	}
+	got := parser.StringStyle(0)
+	if len(got) != len(styles[0]) {
+		t.Fatalf("StringStyle(0) = %+v", got)
+	}
+	for i, span := range styles[0] {
+		if got[i] != span {
+			t.Errorf("span %d = %+v, want %+v", i, got[i], span)
+		}
+	}
+	if spans := parser.StringStyle(1); len(spans) != 0 {
+		t.Errorf("StringStyle(1) = %+v, want no spans", spans)
+	}
+	if spans := parser.StringStyle(2); spans != nil {
+		t.Errorf("StringStyle(2) = %+v, want nil", spans)
+	}
+
+	// a style missing its terminator runs out of the chunk
+	unterminated := pool[:len(pool)-12]
+	binary.LittleEndian.PutUint32(unterminated[4:], uint32(len(unterminated)))
+	data = append(le32(0x00080003, uint32(8+len(unterminated))), unterminated...)
+	if err := New(new(TreeListener)).Parse(data); !errors.Is(err, ErrBadStringPool) {
+		t.Errorf("unterminated style: error = %v, want ErrBadStringPool", err)
+	}
+}
+
+func TestParseTruncated(t *testing.T) {
+	data := testManifest()
+	for _, n := range []int{6, 20, 120, len(data) - 30} {
+		err := New(new(AppNameListener)).Parse(data[:n])
+		if !errors.Is(err, ErrTruncated) && !errors.Is(err, ErrBadStringPool) {
+			t.Errorf("Parse(data[:%d]) = %v, want a truncation error", n, err)
+		}
+		var perr *ParseError
+		if !errors.As(err, &perr) {
+			t.Errorf("Parse(data[:%d]) = %T, want *ParseError", n, err)
+		}
+	}
+}
+
+func TestStringEncodings(t *testing.T) {
+	const label = "应用 😀"
+
+	// UTF-8: 5 UTF-16 units, 11 bytes, then the zero terminator
+	utf8Data := append([]byte{5, 11}, label...)
+	utf8Data = append(utf8Data, 0)
+
+	// UTF-16LE, the emoji as a surrogate pair
+	units := utf16.Encode([]rune(label))
+	utf16Data := []byte{byte(len(units)), 0}
+	for _, u := range units {
+		utf16Data = append(utf16Data, byte(u), byte(u>>8))
+	}
+	utf16Data = append(utf16Data, 0, 0)
+
+	// CESU-8 surrogate pair for U+1F600, as written by some encoders
+	cesu := []byte{2, 6, 0xED, 0xA0, 0xBD, 0xED, 0xB8, 0x80, 0}
+
+	tests := []struct {
+		data   []byte
+		isUTF8 bool
+		want   string
+	}{
+		{utf8Data, true, label},
+		{utf16Data, false, label},
+		{cesu, true, "😀"},
+	}
+	for _, test := range tests {
+		parser := New(nil)
+		parser.Data = test.data
+		got, err := parser.getStringFromStringTable(0, test.isUTF8)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != test.want {
+			t.Errorf("got %q, want %q", got, test.want)
+		}
+	}
+
+	parser := New(nil)
+	parser.Data = utf8Data[:6]
+	if _, err := parser.getStringFromStringTable(0, true); !errors.Is(err, ErrBadStringPool) {
+		t.Errorf("truncated string: got %v, want ErrBadStringPool", err)
+	}
+}
+
+func TestTypedAttributes(t *testing.T) {
+	listener := new(PlainListener)
+	if err := New(listener).Parse(testManifest()); err != nil {
+		t.Fatal(err)
+	}
+
+	attrs := listener.Manifest.Attrs["manifest"]
+	if len(attrs) != 2 {
+		t.Fatalf("got %d attributes, want 2", len(attrs))
+	}
+
+	pkg, code := attrs[0], attrs[1]
+	if pkg.Type != RES_TYPE_STRING || pkg.StringIndex != 5 {
+		t.Errorf("package: type 0x%02x, index %d", pkg.Type, pkg.StringIndex)
+	}
+	if _, err := pkg.Int(); !errors.Is(err, ErrValueType) {
+		t.Errorf("package.Int() error = %v, want ErrValueType", err)
+	}
+
+	if code.NameResourceID != 0x0101021b {
+		t.Errorf("versionCode resource ID = 0x%08x", code.NameResourceID)
+	}
+	if v, err := code.Int(); err != nil || v != 7 {
+		t.Errorf("versionCode.Int() = %d, %v", v, err)
+	}
+	if _, err := code.Bool(); !errors.Is(err, ErrValueType) {
+		t.Errorf("versionCode.Bool() error = %v, want ErrValueType", err)
+	}
+	if code.IsReference() {
+		t.Error("versionCode is not a reference")
+	}
+}
+
+func TestStrippedAttributeNames(t *testing.T) {
+	// blank the "versionCode" string, leaving its resource ID
+	data := bytes.Replace(testManifest(),
+		[]byte("v\x00e\x00r\x00s\x00i\x00o\x00n\x00C\x00o\x00d\x00e\x00"),
+		[]byte("x\x00x\x00x\x00x\x00x\x00x\x00x\x00x\x00x\x00x\x00x\x00"), 1)
+
+	listener := new(AppNameListener)
+	if err := New(listener).Parse(data); err != nil {
+		t.Fatal(err)
+	}
+	if listener.VersionCode != "7" {
+		t.Errorf("VersionCode = %q, want 7", listener.VersionCode)
+	}
+}
@@ -11,14 +11,20 @@ type Manifest struct {
 
 type PlainListener struct {
 	Manifest Manifest
+
+	// tree keeps the whole document for BuildXml
+	tree TreeListener
 }
 
+// BuildXml writes the parsed document as text XML, indented like apktool
+// output.
 func (listener *PlainListener) BuildXml(writer io.Writer) error {
-	return nil
+	return listener.tree.document().WriteXML(writer, "    ")
 }
 
 func (listener *PlainListener) StartDocument() {
 	listener.Manifest.Attrs = make(map[string][]*Attribute)
+	listener.tree.StartDocument()
 }
 
 /**
@@ -37,6 +43,7 @@ func (listener *PlainListener) EndDocument() {
  *            the Namespace URI the prefix is mapped to
  */
 func (listener *PlainListener) StartPrefixMapping(prefix, uri string) {
+	listener.tree.StartPrefixMapping(prefix, uri)
 }
 
 /**
@@ -70,6 +77,7 @@ func (listener *PlainListener) EndPrefixMapping(prefix, uri string) {}
  */
 func (listener *PlainListener) StartElement(uri, localName, qName string,
 	attrs []*Attribute) {
+	listener.tree.StartElement(uri, localName, qName, attrs)
 	for _, attr := range attrs {
 		if _, ok := listener.Manifest.Attrs[localName]; !ok {
 			listener.Manifest.Attrs[localName] = make([]*Attribute, 0)
@@ -93,7 +101,9 @@ func (listener *PlainListener) StartElement(uri, localName, qName string,
  *            the qualified XML name (with prefix), or the empty string if
  *            qualified names are not available
  */
-func (listener *PlainListener) EndElement(uri, localName, qName string) {}
+func (listener *PlainListener) EndElement(uri, localName, qName string) {
+	listener.tree.EndElement(uri, localName, qName)
+}
 
 /**
  * Receive notification of text.
@@ -109,7 +119,9 @@ func (listener *PlainListener) Text(data string) {}
  * @param data
  *            the text data
  */
-func (listener *PlainListener) CharacterData(data string) {}
+func (listener *PlainListener) CharacterData(data string) {
+	listener.tree.CharacterData(data)
+}
 
 /**
  * Receive notification of a processing instruction.
@@ -0,0 +1,141 @@
+package axmlParser
+
+import (
+	"errors"
+	"fmt"
+)
+
+// maxReferenceDepth bounds reference chains, which may loop in broken
+// tables.
+const maxReferenceDepth = 32
+
+// Resolver resolves the resource references found in attribute values.
+type Resolver interface {
+	// ResolveReference returns the formatted value the resource id
+	// points to.
+	ResolveReference(id int) (string, error)
+}
+
+// ResolveReference returns the value of the resource id, following
+// references to other resources. Complex entries, such as styles, and
+// resources of other packages, such as the framework, are left unresolved.
+func (table *ResourceTable) ResolveReference(id int) (string, error) {
+	value, err := table.Resolve(id)
+	if err != nil {
+		return "", err
+	}
+	return table.FormatValue(value), nil
+}
+
+// Resolve returns the value of the resource id for the default
+// configuration, following references to other resources. Resources with
+// no default value resolve to their first one.
+func (table *ResourceTable) Resolve(id int) (ResourceValue, error) {
+	return table.ResolveConfig(id, nil)
+}
+
+// ResolveConfig returns the value of the resource id that best matches
+// config, following references to other resources in the same
+// configuration. A nil config resolves like Resolve.
+func (table *ResourceTable) ResolveConfig(id int, config *ResourceConfig) (ResourceValue, error) {
+	for depth := 0; depth < maxReferenceDepth; depth++ {
+		entry := table.bestEntry(id, config)
+		if entry == nil {
+			return ResourceValue{}, fmt.Errorf("%w: no entry for 0x%08x", ErrUnresolved, id)
+		}
+		if entry.IsComplex() {
+			return ResourceValue{}, fmt.Errorf("%w: 0x%08x is a complex entry", ErrUnresolved, id)
+		}
+
+		value := entry.Value
+		switch value.Type {
+		case RES_TYPE_REFERENCE, RES_TYPE_DYNAMIC_REFERENCE:
+			if value.Data == 0 {
+				return value, nil
+			}
+			id = value.Data
+		default:
+			return value, nil
+		}
+	}
+	return ResourceValue{}, fmt.Errorf("%w: reference loop at 0x%08x", ErrUnresolved, id)
+}
+
+// bestEntry returns the entry of id that best matches config, as Android
+// picks resources for a device. A nil config picks the default entry,
+// falling back to the first one.
+func (table *ResourceTable) bestEntry(id int, config *ResourceConfig) *ResourceEntry {
+	entries := table.Entries(id)
+	if len(entries) == 0 {
+		return nil
+	}
+
+	requested := config
+	if requested == nil {
+		requested = new(ResourceConfig)
+	}
+	var best *ResourceEntry
+	for _, entry := range entries {
+		if !entry.Config.Match(requested) {
+			continue
+		}
+		if best == nil || entry.Config.IsBetterThan(best.Config, requested) {
+			best = entry
+		}
+	}
+	if best == nil && config == nil {
+		best = entries[0]
+	}
+	return best
+}
+
+// WithConfig returns a Resolver picking the values that best match config.
+func (table *ResourceTable) WithConfig(config ResourceConfig) Resolver {
+	return &configResolver{table: table, config: config}
+}
+
+type configResolver struct {
+	table  *ResourceTable
+	config ResourceConfig
+}
+
+func (resolver *configResolver) ResolveReference(id int) (string, error) {
+	value, err := resolver.table.ResolveConfig(id, &resolver.config)
+	if err != nil {
+		return "", err
+	}
+	return resolver.table.FormatValue(value), nil
+}
+
+// LocalizedStrings returns the value of the resource id for every locale
+// it has a value in, by BCP 47 tag such as "zh-CN", the default value being
+// under "".
+func (table *ResourceTable) LocalizedStrings(id int) (map[string]string, error) {
+	values := make(map[string]string)
+	for _, entry := range table.Entries(id) {
+		locale := entry.Config.Locale()
+		if _, ok := values[locale]; ok {
+			continue
+		}
+		config := ResourceConfig{
+			Language:      entry.Config.Language,
+			Country:       entry.Config.Country,
+			LocaleScript:  entry.Config.LocaleScript,
+			LocaleVariant: entry.Config.LocaleVariant,
+		}
+		value, err := table.ResolveConfig(id, &config)
+		if err != nil {
+			// a value qualified by more than its locale, such as a
+			// night only label, has no plain localized value
+			if errors.Is(err, ErrUnresolved) {
+				continue
+			}
+			return nil, err
+		}
+		values[locale] = table.FormatValue(value)
+	}
+	if len(values) == 0 {
+		return nil, fmt.Errorf("%w: no localized value for 0x%08x", ErrUnresolved, id)
+	}
+	return values, nil
+}
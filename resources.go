@@ -0,0 +1,613 @@
+package axmlParser
+
+import (
+	"fmt"
+	"unicode/utf16"
+)
+
+const (
+	// ResTable_type flags
+	TYPE_FLAG_SPARSE   = 0x01
+	TYPE_FLAG_OFFSET16 = 0x02
+
+	// ResTable_entry flags
+	ENTRY_FLAG_COMPLEX = 0x0001
+	ENTRY_FLAG_PUBLIC  = 0x0002
+	ENTRY_FLAG_WEAK    = 0x0004
+	ENTRY_FLAG_COMPACT = 0x0008
+
+	// NO_ENTRY marks a missing entry in the offsets of a ResTable_type
+	NO_ENTRY = 0xFFFFFFFF
+
+	// ResTable_package fields, relative to the chunk
+	packageNameOffset   = 12
+	packageNameLength   = 128
+	packageHeaderSize   = 284
+	typeSpecHeaderSize  = 16
+	typeHeaderMinSize   = 20
+	libraryEntrySize    = 4 + 2*packageNameLength
+	mapEntryHeaderSize  = 16
+	tableMapSize        = 12
+	resValueSize        = 8
+	entryHeaderSize     = 8
+	sparseTypeEntrySize = 4
+)
+
+// ResourceTable is a decoded resources.arsc file.
+type ResourceTable struct {
+	// Strings is the global string pool, indexed by the data of string
+	// values
+	Strings  []string
+	Styles   [][]Span
+	Packages []*ResourcePackage
+
+	parser *Parser
+}
+
+// ResourcePackage holds the resources of one package of a table.
+type ResourcePackage struct {
+	ID   int
+	Name string
+
+	TypeStrings []string
+	KeyStrings  []string
+	// Specs holds the configuration change flags of every entry, by type id
+	Specs map[int][]int
+	// Types holds every configuration of a type, by type id
+	Types map[int][]*ResourceType
+	// Libraries maps the package ids of shared libraries to their names
+	Libraries map[int]string
+}
+
+// ResourceType holds the entries of one type for one configuration.
+// Entries is indexed by entry id and holds nil for missing entries.
+type ResourceType struct {
+	ID      int
+	Name    string
+	Config  ResourceConfig
+	Entries []*ResourceEntry
+}
+
+// ResourceEntry is one value of a resource. Complex entries, such as
+// styles, arrays and plurals, have a Parent and a Map instead of a Value.
+type ResourceEntry struct {
+	ID     int
+	Key    string
+	Flags  int
+	Value  ResourceValue
+	Parent int
+	Map    []ResourceMapEntry
+	Config *ResourceConfig
+}
+
+// IsComplex reports whether entry is a bag of values.
+func (entry *ResourceEntry) IsComplex() bool {
+	return entry.Flags&ENTRY_FLAG_COMPLEX != 0
+}
+
+// ResourceValue is a Res_value, with the same Type and Data as attributes.
+type ResourceValue struct {
+	Type int
+	Data int
+}
+
+// ResourceMapEntry is one value of a complex entry, Name being an
+// attribute resource id or, for arrays and plurals, a special key.
+type ResourceMapEntry struct {
+	Name  int
+	Value ResourceValue
+}
+
+// ResourceConfig is the ResTable_config a type applies to. Fields left out
+// of older tables are zero, which matches any device.
+type ResourceConfig struct {
+	Mcc, Mnc              int
+	Language, Country     string
+	Orientation           int
+	Touchscreen           int
+	Density               int
+	Keyboard, Navigation  int
+	InputFlags            int
+	ScreenWidth           int
+	ScreenHeight          int
+	SdkVersion            int
+	MinorVersion          int
+	ScreenLayout          int
+	UiMode                int
+	SmallestScreenWidthDp int
+	ScreenWidthDp         int
+	ScreenHeightDp        int
+	LocaleScript          string
+	LocaleVariant         string
+	ScreenLayout2         int
+	ColorMode             int
+}
+
+// ParseResourceTable decodes a resources.arsc file.
+func ParseResourceTable(data []byte) (*ResourceTable, error) {
+	parser := New(nil)
+	parser.Data = data
+	parser.end = len(data)
+	parser.chunk = RES_TABLE_TYPE
+
+	header, err := parser.getChunkHeader(0, parser.end)
+	if err != nil {
+		return nil, err
+	}
+	if header.Type != RES_TABLE_TYPE {
+		return nil, parser.errorf(0, ErrBadChunk, "not a resource table")
+	}
+	if header.HeaderSize < 3*WORD_SIZE {
+		return nil, parser.errorf(0, ErrBadChunk, "header size %d too small", header.HeaderSize)
+	}
+
+	table := &ResourceTable{parser: parser}
+	end := header.Offset + header.Size
+	for off := header.Offset + header.HeaderSize; off < end; {
+		parser.chunk = RES_TABLE_TYPE
+		chunk, err := parser.getChunkHeader(off, end)
+		if err != nil {
+			return nil, err
+		}
+		parser.chunk = chunk.Type
+
+		switch chunk.Type {
+		case RES_STRING_POOL_TYPE:
+			// values of type string index the global pool
+			if err := parser.parseStringTable(chunk); err != nil {
+				return nil, err
+			}
+			table.Strings = parser.StringsTable
+			table.Styles = parser.StylesTable
+		case RES_TABLE_PACKAGE_TYPE:
+			pkg, err := parser.parsePackage(chunk)
+			if err != nil {
+				return nil, err
+			}
+			table.Packages = append(table.Packages, pkg)
+		}
+		off += chunk.Size
+	}
+
+	return table, nil
+}
+
+/**
+ * A ResTable_package chunk holds, after the chunk header :
+ * <ul>
+ * <li>uint32 : package id</li>
+ * <li>uint16[128] : package name</li>
+ * <li>uint32 : offset of the type strings</li>
+ * <li>uint32 : last public type</li>
+ * <li>uint32 : offset of the key strings</li>
+ * <li>uint32 : last public key</li>
+ * </ul>
+ * followed by the string pools, type specs and types.
+ */
+func (parser *Parser) parsePackage(header chunkHeader) (*ResourcePackage, error) {
+	if header.HeaderSize < packageHeaderSize {
+		return nil, parser.errorf(header.Offset, ErrBadChunk,
+			"package header size %d too small", header.HeaderSize)
+	}
+	id, err := parser.getLEWord(header.Offset + 2*WORD_SIZE)
+	if err != nil {
+		return nil, err
+	}
+	name, err := parser.getFixedUTF16String(header.Offset+packageNameOffset, packageNameLength)
+	if err != nil {
+		return nil, err
+	}
+	offsets, err := parser.getLEWords(header.Offset+packageNameOffset+2*packageNameLength, 3)
+	if err != nil {
+		return nil, err
+	}
+	typeStrings, keyStrings := header.Offset+offsets[0], header.Offset+offsets[2]
+
+	pkg := &ResourcePackage{
+		ID:        id,
+		Name:      name,
+		Specs:     make(map[int][]int),
+		Types:     make(map[int][]*ResourceType),
+		Libraries: make(map[int]string),
+	}
+	end := header.Offset + header.Size
+	for off := header.Offset + header.HeaderSize; off < end; {
+		parser.chunk = RES_TABLE_PACKAGE_TYPE
+		chunk, err := parser.getChunkHeader(off, end)
+		if err != nil {
+			return nil, err
+		}
+		parser.chunk = chunk.Type
+
+		switch chunk.Type {
+		case RES_STRING_POOL_TYPE:
+			strs, _, err := parser.readStringPool(chunk)
+			if err != nil {
+				return nil, err
+			}
+			switch off {
+			case typeStrings:
+				pkg.TypeStrings = strs
+			case keyStrings:
+				pkg.KeyStrings = strs
+			}
+		case RES_TABLE_TYPE_SPEC_TYPE:
+			if err := parser.parseTypeSpec(chunk, pkg); err != nil {
+				return nil, err
+			}
+		case RES_TABLE_TYPE_TYPE:
+			typ, err := parser.parseType(chunk, pkg)
+			if err != nil {
+				return nil, err
+			}
+			pkg.Types[typ.ID] = append(pkg.Types[typ.ID], typ)
+		case RES_TABLE_LIBRARY_TYPE:
+			if err := parser.parseLibrary(chunk, pkg); err != nil {
+				return nil, err
+			}
+		}
+		off += chunk.Size
+	}
+
+	return pkg, nil
+}
+
+// parseTypeSpec reads the configuration change flags of the entries of a
+// type.
+func (parser *Parser) parseTypeSpec(header chunkHeader, pkg *ResourcePackage) error {
+	if header.HeaderSize < typeSpecHeaderSize {
+		return parser.errorf(header.Offset, ErrBadChunk,
+			"type spec header size %d too small", header.HeaderSize)
+	}
+	id := int(parser.Data[header.Offset+2*WORD_SIZE])
+	count, err := parser.getLEWord(header.Offset + 3*WORD_SIZE)
+	if err != nil {
+		return err
+	}
+	if count > (header.Size-header.HeaderSize)/WORD_SIZE {
+		return parser.errorf(header.Offset, ErrBadChunk,
+			"%d entries do not fit in chunk of %d bytes", count, header.Size)
+	}
+	flags, err := parser.getLEWords(header.Offset+header.HeaderSize, count)
+	if err != nil {
+		return err
+	}
+	pkg.Specs[id] = flags
+	return nil
+}
+
+/**
+ * A ResTable_type chunk holds, after the chunk header :
+ * <ul>
+ * <li>uint8 : type id</li>
+ * <li>uint8 : flags</li>
+ * <li>uint16 : reserved</li>
+ * <li>uint32 : entry count</li>
+ * <li>uint32 : offset of the entries data</li>
+ * <li>ResTable_config : the configuration</li>
+ * </ul>
+ * followed by the offsets of the entries.
+ */
+func (parser *Parser) parseType(header chunkHeader, pkg *ResourcePackage) (*ResourceType, error) {
+	if header.HeaderSize < typeHeaderMinSize {
+		return nil, parser.errorf(header.Offset, ErrBadChunk,
+			"type header size %d too small", header.HeaderSize)
+	}
+	id := int(parser.Data[header.Offset+2*WORD_SIZE])
+	flags := int(parser.Data[header.Offset+2*WORD_SIZE+1])
+	words, err := parser.getLEWords(header.Offset+3*WORD_SIZE, 2)
+	if err != nil {
+		return nil, err
+	}
+	count, entriesStart := words[0], header.Offset+words[1]
+	if id == 0 || words[1] > header.Size {
+		return nil, parser.errorf(header.Offset, ErrBadChunk,
+			"type %d with entries at %d", id, words[1])
+	}
+
+	typ := &ResourceType{ID: id}
+	if id <= len(pkg.TypeStrings) {
+		typ.Name = pkg.TypeStrings[id-1]
+	}
+	typ.Config, err = parser.getConfig(header.Offset+typeHeaderMinSize,
+		header.Offset+header.HeaderSize)
+	if err != nil {
+		return nil, err
+	}
+
+	// the offsets of the entries, by entry id
+	offsets := make(map[int]int)
+	indexOffset := header.Offset + header.HeaderSize
+	offsetSize := WORD_SIZE
+	switch {
+	case flags&TYPE_FLAG_SPARSE != 0:
+		offsetSize = sparseTypeEntrySize
+	case flags&TYPE_FLAG_OFFSET16 != 0:
+		offsetSize = 2
+	}
+	maxEntries := (header.Size - header.HeaderSize) / offsetSize
+	if count > maxEntries {
+		return nil, parser.errorf(header.Offset, ErrBadChunk,
+			"%d entries do not fit in chunk of %d bytes", count, header.Size)
+	}
+	entryCount := count
+	for i := 0; i < count; i++ {
+		switch {
+		case flags&TYPE_FLAG_SPARSE != 0:
+			idx, err := parser.getLEShort(indexOffset + i*sparseTypeEntrySize)
+			if err != nil {
+				return nil, err
+			}
+			offset, err := parser.getLEShort(indexOffset + i*sparseTypeEntrySize + 2)
+			if err != nil {
+				return nil, err
+			}
+			offsets[idx] = offset * 4
+			if idx >= entryCount {
+				entryCount = idx + 1
+			}
+		case flags&TYPE_FLAG_OFFSET16 != 0:
+			offset, err := parser.getLEShort(indexOffset + i*2)
+			if err != nil {
+				return nil, err
+			}
+			if offset != 0xFFFF {
+				offsets[i] = offset * 4
+			}
+		default:
+			offset, err := parser.getLEWord(indexOffset + i*WORD_SIZE)
+			if err != nil {
+				return nil, err
+			}
+			if offset != NO_ENTRY {
+				offsets[i] = offset
+			}
+		}
+	}
+
+	typ.Entries = make([]*ResourceEntry, entryCount)
+	for i, offset := range offsets {
+		entry, err := parser.getEntry(entriesStart+offset, header.Offset+header.Size, pkg)
+		if err != nil {
+			return nil, err
+		}
+		entry.ID = pkg.ID<<24 | id<<16 | i
+		entry.Config = &typ.Config
+		typ.Entries[i] = entry
+	}
+
+	return typ, nil
+}
+
+/**
+ * A ResTable_entry is :
+ * <ul>
+ * <li>uint16 : size of the entry header</li>
+ * <li>uint16 : flags</li>
+ * <li>uint32 : index of the key in the key strings</li>
+ * </ul>
+ * followed by a Res_value for simple entries, or by the parent and count of
+ * a ResTable_map_entry and its ResTable_map values for complex ones. Compact
+ * entries pack the key, the value type and the data in the same 8 bytes.
+ */
+func (parser *Parser) getEntry(offset, end int, pkg *ResourcePackage) (*ResourceEntry, error) {
+	if offset+entryHeaderSize > end {
+		return nil, parser.errorf(offset, ErrTruncated, "entry outside chunk")
+	}
+	size, _ := parser.getLEShort(offset)
+	flags, _ := parser.getLEShort(offset + 2)
+	key, _ := parser.getLEWord(offset + WORD_SIZE)
+
+	entry := &ResourceEntry{Flags: flags}
+	if flags&ENTRY_FLAG_COMPACT != 0 {
+		entry.Key = getPoolString(pkg.KeyStrings, size)
+		entry.Value = ResourceValue{Type: flags >> 8, Data: key}
+		return entry, nil
+	}
+	entry.Key = getPoolString(pkg.KeyStrings, key)
+
+	if flags&ENTRY_FLAG_COMPLEX == 0 {
+		if offset+size+resValueSize > end {
+			return nil, parser.errorf(offset, ErrTruncated, "entry value outside chunk")
+		}
+		value, err := parser.getResValue(offset + size)
+		if err != nil {
+			return nil, err
+		}
+		entry.Value = value
+		return entry, nil
+	}
+
+	if size < mapEntryHeaderSize || offset+size > end {
+		return nil, parser.errorf(offset, ErrBadChunk, "map entry of size %d", size)
+	}
+	words, err := parser.getLEWords(offset+2*WORD_SIZE, 2)
+	if err != nil {
+		return nil, err
+	}
+	count := words[1]
+	if count > (end-offset-size)/tableMapSize {
+		return nil, parser.errorf(offset, ErrTruncated, "%d map values outside chunk", count)
+	}
+	entry.Parent = words[0]
+	entry.Map = make([]ResourceMapEntry, count)
+	for i := range entry.Map {
+		mapOffset := offset + size + i*tableMapSize
+		name, err := parser.getLEWord(mapOffset)
+		if err != nil {
+			return nil, err
+		}
+		value, err := parser.getResValue(mapOffset + WORD_SIZE)
+		if err != nil {
+			return nil, err
+		}
+		entry.Map[i] = ResourceMapEntry{Name: name, Value: value}
+	}
+	return entry, nil
+}
+
+// getResValue reads a Res_value : size, padding, type and data.
+func (parser *Parser) getResValue(offset int) (ResourceValue, error) {
+	data, err := parser.getLEWord(offset + WORD_SIZE)
+	if err != nil {
+		return ResourceValue{}, err
+	}
+	return ResourceValue{Type: int(parser.Data[offset+3]), Data: data}, nil
+}
+
+// parseLibrary reads the package ids assigned to shared libraries.
+func (parser *Parser) parseLibrary(header chunkHeader, pkg *ResourcePackage) error {
+	count, err := parser.getLEWord(header.Offset + 2*WORD_SIZE)
+	if err != nil {
+		return err
+	}
+	if count > (header.Size-header.HeaderSize)/libraryEntrySize {
+		return parser.errorf(header.Offset, ErrBadChunk,
+			"%d libraries do not fit in chunk of %d bytes", count, header.Size)
+	}
+	for i := 0; i < count; i++ {
+		offset := header.Offset + header.HeaderSize + i*libraryEntrySize
+		id, err := parser.getLEWord(offset)
+		if err != nil {
+			return err
+		}
+		name, err := parser.getFixedUTF16String(offset+WORD_SIZE, packageNameLength)
+		if err != nil {
+			return err
+		}
+		pkg.Libraries[id] = name
+	}
+	return nil
+}
+
+// getConfig reads the ResTable_config at offset, which starts with its own
+// size and must end before end.
+func (parser *Parser) getConfig(offset, end int) (ResourceConfig, error) {
+	var config ResourceConfig
+	size, err := parser.getLEWord(offset)
+	if err != nil {
+		return config, err
+	}
+	if size < WORD_SIZE || size > end-offset {
+		return config, parser.errorf(offset, ErrBadChunk, "config of size %d", size)
+	}
+
+	// fields past the size of the config are zero
+	b := make([]byte, 64)
+	copy(b, parser.Data[offset:offset+size])
+	u16 := func(i int) int { return int(b[i]) | int(b[i+1])<<8 }
+
+	config.Mcc = u16(4)
+	config.Mnc = u16(6)
+	config.Language = unpackLocale(b[8], b[9], 'a')
+	config.Country = unpackLocale(b[10], b[11], '0')
+	config.Orientation = int(b[12])
+	config.Touchscreen = int(b[13])
+	config.Density = u16(14)
+	config.Keyboard = int(b[16])
+	config.Navigation = int(b[17])
+	config.InputFlags = int(b[18])
+	config.ScreenWidth = u16(20)
+	config.ScreenHeight = u16(22)
+	config.SdkVersion = u16(24)
+	config.MinorVersion = u16(26)
+	config.ScreenLayout = int(b[28])
+	config.UiMode = int(b[29])
+	config.SmallestScreenWidthDp = u16(30)
+	config.ScreenWidthDp = u16(32)
+	config.ScreenHeightDp = u16(34)
+	config.LocaleScript = trimNUL(b[36:40])
+	config.LocaleVariant = trimNUL(b[40:48])
+	config.ScreenLayout2 = int(b[48])
+	config.ColorMode = int(b[49])
+	return config, nil
+}
+
+// unpackLocale decodes a language or country code, packed into 3 letters
+// of 5 bits when the high bit is set.
+func unpackLocale(b0, b1 byte, base byte) string {
+	if b0&0x80 != 0 {
+		first := b1 & 0x1f
+		second := (b1&0xe0)>>5 | (b0&0x03)<<3
+		third := (b0 & 0x7c) >> 2
+		return string([]byte{base + first, base + second, base + third})
+	}
+	return trimNUL([]byte{b0, b1})
+}
+
+func trimNUL(b []byte) string {
+	for i, c := range b {
+		if c == 0 {
+			return string(b[:i])
+		}
+	}
+	return string(b)
+}
+
+// getFixedUTF16String reads a 0 terminated UTF-16 string stored in a field
+// of length units.
+func (parser *Parser) getFixedUTF16String(offset, length int) (string, error) {
+	if offset+2*length > len(parser.Data) {
+		return "", parser.errorf(offset, ErrTruncated, "name outside data")
+	}
+	chars := make([]uint16, 0, length)
+	for i := 0; i < length; i++ {
+		c, _ := parser.getLEShort(offset + 2*i)
+		if c == 0 {
+			break
+		}
+		chars = append(chars, uint16(c))
+	}
+	return string(utf16.Decode(chars)), nil
+}
+
+func getPoolString(strs []string, index int) string {
+	if index >= 0 && index < len(strs) {
+		return strs[index]
+	}
+	return ""
+}
+
+// Package returns the package with the given id, or nil.
+func (table *ResourceTable) Package(id int) *ResourcePackage {
+	for _, pkg := range table.Packages {
+		if pkg.ID == id {
+			return pkg
+		}
+	}
+	return nil
+}
+
+// Entries returns the values of the resource id in every configuration.
+func (table *ResourceTable) Entries(id int) []*ResourceEntry {
+	pkg := table.Package(id >> 24 & 0xFF)
+	if pkg == nil {
+		return nil
+	}
+	entryID := id & 0xFFFF
+	entries := make([]*ResourceEntry, 0)
+	for _, typ := range pkg.Types[id>>16&0xFF] {
+		if entryID < len(typ.Entries) && typ.Entries[entryID] != nil {
+			entries = append(entries, typ.Entries[entryID])
+		}
+	}
+	return entries
+}
+
+// ResourceName returns the name of the resource id, such as
+// "com.example:string/app_name".
+func (table *ResourceTable) ResourceName(id int) (string, bool) {
+	entries := table.Entries(id)
+	if len(entries) == 0 {
+		return "", false
+	}
+	pkg := table.Package(id >> 24 & 0xFF)
+	typeName := getPoolString(pkg.TypeStrings, (id>>16&0xFF)-1)
+	return fmt.Sprintf("%s:%s/%s", pkg.Name, typeName, entries[0].Key), true
+}
+
+// FormatValue formats v like an attribute value, taking strings from the
+// global string pool.
+func (table *ResourceTable) FormatValue(v ResourceValue) string {
+	return table.parser.getAttributeValue(v.Type, v.Data)
+}
@@ -0,0 +1,183 @@
+package axmlParser
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"testing"
+)
+
+// testConfig encodes a 28 byte ResTable_config for language and sdk.
+func testConfig(language string, sdk int) []byte {
+	config := make([]byte, 28)
+	binary.LittleEndian.PutUint32(config, 28)
+	copy(config[8:10], language)
+	binary.LittleEndian.PutUint16(config[24:], uint16(sdk))
+	return config
+}
+
+// testTypeChunk encodes a ResTable_type whose entries are given already
+// encoded, nil for missing ones.
+func testTypeChunk(id int, config []byte, entries ...[]byte) []byte {
+	headerSize := 20 + len(config)
+	var offsets, data []byte
+	for _, entry := range entries {
+		if entry == nil {
+			offsets = append(offsets, le32(NO_ENTRY)...)
+			continue
+		}
+		offsets = append(offsets, le32(uint32(len(data)))...)
+		data = append(data, entry...)
+	}
+	start := headerSize + len(offsets)
+	chunk := le32(uint32(headerSize)<<16|RES_TABLE_TYPE_TYPE, uint32(start+len(data)),
+		uint32(id), uint32(len(entries)), uint32(start))
+	chunk = append(chunk, config...)
+	chunk = append(append(chunk, offsets...), data...)
+	return chunk
+}
+
+// testPackage encodes the package 0x7f "com.example" holding chunks.
+func testPackage(types, keys []string, chunks ...[]byte) []byte {
+	typeStrings := testStringPool(types...)
+	keyStrings := testStringPool(keys...)
+
+	var body []byte
+	body = append(body, typeStrings...)
+	body = append(body, keyStrings...)
+	for _, chunk := range chunks {
+		body = append(body, chunk...)
+	}
+
+	name := make([]byte, 256)
+	for i, c := range "com.example" {
+		name[2*i] = byte(c)
+	}
+	header := append(le32(0x01200200, uint32(288+len(body)), 0x7f), name...)
+	header = append(header, le32(288, uint32(len(types)), uint32(288+len(typeStrings)),
+		uint32(len(keys)), 0)...)
+	return append(header, body...)
+}
+
+// testTable encodes a resource table with the global strings and package.
+func testTable(globals []string, pkg []byte) []byte {
+	pool := testStringPool(globals...)
+	table := le32(0x000C0002, uint32(12+len(pool)+len(pkg)), 1)
+	table = append(table, pool...)
+	return append(table, pkg...)
+}
+
+// testResourceTable returns a table for package 0x7f "com.example" with:
+//
+//	0x7f010000 string/app_name "Example", "Exemple" in fr
+//	0x7f010001 string/title, a reference to app_name
+//	0x7f020001 style/AppTheme, parent 0x01030005, with textSize 5
+func testResourceTable() []byte {
+	appName := func(s uint32) []byte {
+		return le32(0x00000008, 0, 0x03000008, s)
+	}
+	title := le32(0x00000008, 2, 0x01000008, 0x7f010000)
+	theme := le32(0x00010010, 1, 0x01030005, 1, 0x01010095, 0x10000008, 5)
+
+	pkg := testPackage([]string{"string", "style"}, []string{"app_name", "AppTheme", "title"},
+		le32(0x00100202, 24, 1, 2, 0, 0),
+		testTypeChunk(1, testConfig("", 0), appName(0), title),
+		testTypeChunk(1, testConfig("fr", 0), appName(1)),
+		testTypeChunk(2, testConfig("", 0), nil, theme))
+	return testTable([]string{"Example", "Exemple"}, pkg)
+}
+
+func TestParseResourceTable(t *testing.T) {
+	table, err := ParseResourceTable(testResourceTable())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(table.Packages) != 1 || table.Packages[0].Name != "com.example" {
+		t.Fatalf("packages = %+v", table.Packages)
+	}
+
+	entries := table.Entries(0x7f010000)
+	if len(entries) != 2 {
+		t.Fatalf("got %d app_name entries, want 2", len(entries))
+	}
+	for i, want := range []string{"Example", "Exemple"} {
+		if got := table.FormatValue(entries[i].Value); got != want {
+			t.Errorf("entry %d = %q, want %q", i, got, want)
+		}
+	}
+	if lang := entries[1].Config.Language; lang != "fr" {
+		t.Errorf("Language = %q, want fr", lang)
+	}
+	if name, _ := table.ResourceName(0x7f010000); name != "com.example:string/app_name" {
+		t.Errorf("ResourceName = %q", name)
+	}
+
+	theme := table.Entries(0x7f020001)
+	if len(theme) != 1 || !theme[0].IsComplex() {
+		t.Fatalf("theme = %+v", theme)
+	}
+	if theme[0].Parent != 0x01030005 || len(theme[0].Map) != 1 ||
+		theme[0].Map[0].Name != 0x01010095 || theme[0].Map[0].Value.Data != 5 {
+		t.Errorf("theme = %+v", theme[0])
+	}
+	if entries := table.Entries(0x7f020000); len(entries) != 0 {
+		t.Errorf("missing entry = %+v", entries)
+	}
+
+	data := testResourceTable()
+	if _, err := ParseResourceTable(data[:len(data)-10]); !errors.Is(err, ErrTruncated) {
+		t.Errorf("truncated table: got %v, want ErrTruncated", err)
+	}
+
+	// 4 byte offsets for as many entries as 2 byte ones would fit
+	chunk := testTypeChunk(1, testConfig("", 0), le32(0x00000008, 0, 0x10000008, 1))
+	headerSize := binary.LittleEndian.Uint16(chunk[2:])
+	binary.LittleEndian.PutUint32(chunk[12:], (uint32(len(chunk))-uint32(headerSize))/2)
+	data = testTable(nil, testPackage([]string{"integer"}, []string{"one"}, chunk))
+	if _, err := ParseResourceTable(data); !errors.Is(err, ErrBadChunk) {
+		t.Errorf("too many entries: got %v, want ErrBadChunk", err)
+	}
+}
+
+func TestUnpackLocale(t *testing.T) {
+	// "fil" packed as in ResTable_config
+	if got := unpackLocale(0xAD, 0x05, 'a'); got != "fil" {
+		t.Errorf("unpackLocale = %q, want fil", got)
+	}
+	if got := unpackLocale('e', 'n', 'a'); got != "en" {
+		t.Errorf("unpackLocale = %q, want en", got)
+	}
+}
+
+func TestResolveReference(t *testing.T) {
+	table, err := ParseResourceTable(testResourceTable())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []int{0x7f010000, 0x7f010001} {
+		if got, err := table.ResolveReference(id); err != nil || got != "Example" {
+			t.Errorf("ResolveReference(0x%08x) = %q, %v", id, got, err)
+		}
+	}
+	for _, id := range []int{0x7f020001, 0x7f010005, 0x01040000} {
+		if _, err := table.ResolveReference(id); !errors.Is(err, ErrUnresolved) {
+			t.Errorf("ResolveReference(0x%08x) = %v, want ErrUnresolved", id, err)
+		}
+	}
+
+	// make versionCode a reference to app_name
+	data := bytes.Replace(testManifest(), le32(0x10000008, 7), le32(0x01000008, 0x7f010000), 1)
+	listener := new(AppNameListener)
+	parser := New(listener)
+	parser.Resolver = table
+	if err := parser.Parse(data); err != nil {
+		t.Fatal(err)
+	}
+	if listener.VersionCode != "Example" {
+		t.Errorf("VersionCode = %q, want Example", listener.VersionCode)
+	}
+	attr := listener.tree.Document.Root.Attr(ANDROID_NAMESPACE, "versionCode")
+	if id, _ := attr.ResourceID(); id != 0x7f010000 {
+		t.Errorf("ResourceID = 0x%08x, want the raw reference", id)
+	}
+}
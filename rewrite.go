@@ -0,0 +1,236 @@
+package axmlParser
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// alignmentExtraID is the extra field zipalign pads stored entries
+	// with, holding the alignment and zeros
+	alignmentExtraID = 0xD935
+	// dataDescriptorFlag tells that the CRC and sizes follow the data
+	dataDescriptorFlag = 0x8
+	// zip64ExtraID is the extra field of the 64 bit sizes and offset
+	zip64ExtraID = 0x0001
+	// zipLocalHeaderSize is the size of a local file header, before the
+	// name and the extra field
+	zipLocalHeaderSize = 30
+)
+
+// RewriteApk copies the apk at in to out with its manifest modified by
+// edit. Other entries are copied unchanged, in the same order and with the
+// same compression method, stored entries being aligned like zipalign
+// does. in and out may be the same file, out getting the mode of in.
+//
+// The signature of in does not hold for out, and RewriteApk marks it so by
+// deleting it: the v1 signature entries, META-INF/MANIFEST.MF and the
+// META-INF/*.SF, *.RSA, *.DSA and *.EC files, are not copied, nor is the
+// APK signing block. out is unsigned and must be signed again before it
+// can be installed.
+func RewriteApk(in, out string, edit func(*Document) error) error {
+	r, err := zip.OpenReader(in)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	info, err := os.Stat(in)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(out), ".rewrite-*.apk")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+	if err := f.Chmod(info.Mode().Perm()); err != nil {
+		return err
+	}
+
+	if err := rewriteZip(f, &r.Reader, edit); err != nil {
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), out)
+}
+
+// rewriteZip writes r to out with its manifest modified by edit.
+func rewriteZip(out io.Writer, r *zip.Reader, edit func(*Document) error) error {
+	cw := &countWriter{w: out}
+	w := zip.NewWriter(cw)
+	if err := w.SetComment(r.Comment); err != nil {
+		return err
+	}
+
+	found := false
+	for _, f := range r.File {
+		// the signature is invalidated by the new manifest
+		if isSignatureFile(f.Name) {
+			continue
+		}
+		fh := f.FileHeader
+		var data io.Reader
+		if f.Name == "AndroidManifest.xml" {
+			found = true
+			manifest, err := rewriteManifest(f, edit)
+			if err != nil {
+				return err
+			}
+			if data, err = compressEntry(&fh, manifest); err != nil {
+				return err
+			}
+		} else {
+			rc, err := f.OpenRaw()
+			if err != nil {
+				return err
+			}
+			data = rc
+		}
+
+		// sizes and CRC are known, so they go in the local header and
+		// every entry starts where the previous one ends
+		fh.Flags &^= dataDescriptorFlag
+		if err := w.Flush(); err != nil {
+			return err
+		}
+		fh.Extra = alignExtra(&fh, cw.n)
+		fw, err := w.CreateRaw(&fh)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(fw, data); err != nil {
+			return err
+		}
+	}
+	if !found {
+		return ErrNoManifest
+	}
+	return w.Close()
+}
+
+// rewriteManifest returns the binary XML of the manifest f modified by
+// edit.
+func rewriteManifest(f *zip.File, edit func(*Document) error) ([]byte, error) {
+	data, err := readZipEntry(f)
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := ParseDocument(data)
+	if err != nil {
+		return nil, err
+	}
+	if err := edit(doc); err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := Encode(&buf, doc); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// compressEntry compresses data with the method of fh, setting its CRC
+// and sizes.
+func compressEntry(fh *zip.FileHeader, data []byte) (io.Reader, error) {
+	fh.CRC32 = crc32.ChecksumIEEE(data)
+	fh.UncompressedSize64 = uint64(len(data))
+	switch fh.Method {
+	case zip.Store:
+		fh.CompressedSize64 = fh.UncompressedSize64
+		return bytes.NewReader(data), nil
+	case zip.Deflate:
+		var buf bytes.Buffer
+		fw, err := flate.NewWriter(&buf, flate.BestCompression)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := fw.Write(data); err != nil {
+			return nil, err
+		}
+		if err := fw.Close(); err != nil {
+			return nil, err
+		}
+		fh.CompressedSize64 = uint64(buf.Len())
+		return &buf, nil
+	}
+	return nil, fmt.Errorf("%w: method %d for %s", ErrUnsupportedCompression, fh.Method, fh.Name)
+}
+
+// isSignatureFile reports whether name is a file of a v1 signature.
+func isSignatureFile(name string) bool {
+	dir, file := path.Split(name)
+	if dir != "META-INF/" {
+		return false
+	}
+	switch strings.ToUpper(path.Ext(file)) {
+	case ".SF", ".RSA", ".DSA", ".EC":
+		return true
+	}
+	return file == "MANIFEST.MF"
+}
+
+// entryAlignment returns the alignment of the data of a stored entry:
+// native libraries are aligned on pages so they can be mapped from the apk.
+func entryAlignment(name string) int {
+	if strings.HasSuffix(name, ".so") {
+		return 16384
+	}
+	return 4
+}
+
+// alignExtra returns the extra field of fh for a local header written at
+// offset, padded so that the data of stored entries is aligned. Padding
+// left by earlier alignments is dropped.
+func alignExtra(fh *zip.FileHeader, offset int64) []byte {
+	var extra []byte
+	for b := fh.Extra; len(b) >= 4; {
+		id := binary.LittleEndian.Uint16(b)
+		size := int(binary.LittleEndian.Uint16(b[2:])) + 4
+		if size > len(b) {
+			break
+		}
+		// the writer adds zip64 fields itself
+		if id != alignmentExtraID && id != zip64ExtraID && id != 0 {
+			extra = append(extra, b[:size]...)
+		}
+		b = b[size:]
+	}
+	if fh.Method != zip.Store {
+		return extra
+	}
+
+	align := entryAlignment(fh.Name)
+	start := offset + zipLocalHeaderSize + int64(len(fh.Name)+len(extra)) + 6
+	pad := (align - int(start%int64(align))) % align
+	field := make([]byte, 6+pad)
+	binary.LittleEndian.PutUint16(field, alignmentExtraID)
+	binary.LittleEndian.PutUint16(field[2:], uint16(2+pad))
+	binary.LittleEndian.PutUint16(field[4:], uint16(align))
+	return append(extra, field...)
+}
+
+// countWriter counts the bytes written through it.
+type countWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
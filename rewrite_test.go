@@ -0,0 +1,121 @@
+package axmlParser
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testApk returns an apk with the manifest of encoderManifest and the
+// given entries, stored when their name ends with a "0" or ".so".
+func testApk(t *testing.T, names ...string) []byte {
+	doc, err := ParseXML(strings.NewReader(encoderManifest))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var manifest bytes.Buffer
+	if err := Encode(&manifest, doc); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	add := func(name string, method uint16, data []byte) {
+		fw, err := w.CreateHeader(&zip.FileHeader{Name: name, Method: method})
+		if err != nil {
+			t.Fatal(err)
+		}
+		fw.Write(data)
+	}
+	add("AndroidManifest.xml", zip.Deflate, manifest.Bytes())
+	for _, name := range names {
+		method := zip.Deflate
+		if strings.HasSuffix(name, "0") || strings.HasSuffix(name, ".so") {
+			method = zip.Store
+		}
+		add(name, method, []byte("content of "+name))
+	}
+	w.SetComment("test apk")
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestRewriteApk(t *testing.T) {
+	apk := filepath.Join(t.TempDir(), "test.apk")
+	names := []string{"classes.dex", "res/raw/a0", "META-INF/CERT.SF", "res/raw/bc0", "lib/x.so0", "lib/arm64-v8a/libx.so",
+		"META-INF/MANIFEST.MF"}
+	if err := os.WriteFile(apk, testApk(t, names...), 0640); err != nil {
+		t.Fatal(err)
+	}
+
+	err := RewriteApk(apk, apk, func(doc *Document) error {
+		_, err := doc.Find("application").SetAttr(ANDROID_NAMESPACE, "debuggable", "false")
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Stat(apk); err != nil || info.Mode().Perm() != 0640 {
+		t.Errorf("rewritten apk mode = %v, %v, want 0640", info.Mode(), err)
+	}
+
+	r, err := zip.OpenReader(apk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	if r.Comment != "test apk" {
+		t.Errorf("comment = %q", r.Comment)
+	}
+	want := []string{"AndroidManifest.xml", "classes.dex", "res/raw/a0", "res/raw/bc0", "lib/x.so0",
+		"lib/arm64-v8a/libx.so"}
+	if len(r.File) != len(want) {
+		t.Fatalf("%d entries, want %d", len(r.File), len(want))
+	}
+	for i, f := range r.File {
+		if f.Name != want[i] {
+			t.Errorf("entry %d = %s, want %s", i, f.Name, want[i])
+			continue
+		}
+		if i == 0 {
+			continue
+		}
+		data, err := readZipFile(&r.Reader, f.Name)
+		if err != nil || string(data) != "content of "+f.Name {
+			t.Errorf("%s = %q, %v", f.Name, data, err)
+		}
+		stored := strings.HasSuffix(f.Name, "0") || strings.HasSuffix(f.Name, ".so")
+		if stored != (f.Method == zip.Store) {
+			t.Errorf("%s compression method = %d", f.Name, f.Method)
+		}
+		if f.Method == zip.Store {
+			offset, err := f.DataOffset()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if offset%4 != 0 {
+				t.Errorf("%s data at offset %d", f.Name, offset)
+			}
+			if strings.HasSuffix(f.Name, ".so") && offset%16384 != 0 {
+				t.Errorf("native library data at offset %d, want page aligned", offset)
+			}
+		}
+	}
+
+	data, err := readZipFile(&r.Reader, "AndroidManifest.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	manifest, err := ParseManifest(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if manifest.Application.Debuggable || manifest.VersionCode != 12 {
+		t.Errorf("manifest = %+v", manifest)
+	}
+}
@@ -0,0 +1,9 @@
+package axmlParser
+
+// Span is a styled range of a string in the string pool. Name is the tag
+// applied to the range, such as "b" or "a;href=http://example.com", and
+// FirstChar and LastChar are inclusive indexes in UTF-16 units.
+type Span struct {
+	Name                string
+	FirstChar, LastChar int
+}
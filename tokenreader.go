@@ -0,0 +1,58 @@
+package axmlParser
+
+import (
+	"encoding/xml"
+)
+
+// TokenReader adapts a Decoder to encoding/xml, so a binary document can be
+// decoded with xml.NewTokenDecoder(r).Decode(&v). Element and attribute
+// names carry the namespace URI in Name.Space, and namespace declarations
+// are reported as xmlns attributes on the element that follows them.
+type TokenReader struct {
+	decoder *Decoder
+	xmlns   []xml.Attr
+}
+
+func NewTokenReader(data []byte) *TokenReader {
+	return &TokenReader{decoder: NewDecoder(data)}
+}
+
+// Token returns the next xml.StartElement, xml.EndElement or xml.CharData,
+// or io.EOF at the end of the document.
+func (r *TokenReader) Token() (xml.Token, error) {
+	for {
+		token, err := r.decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := token.(type) {
+		case StartNamespace:
+			name := xml.Name{Space: "xmlns", Local: t.Prefix}
+			if t.Prefix == "" {
+				name = xml.Name{Local: "xmlns"}
+			}
+			r.xmlns = append(r.xmlns, xml.Attr{Name: name, Value: t.URI})
+		case StartElement:
+			attrs := make([]xml.Attr, 0, len(r.xmlns)+len(t.Attrs))
+			attrs = append(attrs, r.xmlns...)
+			r.xmlns = nil
+			for _, attr := range t.Attrs {
+				attrs = append(attrs, xml.Attr{
+					Name:  xml.Name{Space: attr.Namespace, Local: attr.Name},
+					Value: attr.Value,
+				})
+			}
+			return xml.StartElement{
+				Name: xml.Name{Space: t.Namespace, Local: t.Name},
+				Attr: attrs,
+			}, nil
+		case EndElement:
+			return xml.EndElement{
+				Name: xml.Name{Space: t.Namespace, Local: t.Name},
+			}, nil
+		case CharData:
+			return xml.CharData(t.Data), nil
+		}
+	}
+}
@@ -0,0 +1,22 @@
+package axmlParser
+
+import (
+	"encoding/xml"
+	"testing"
+)
+
+func TestTokenReaderDecode(t *testing.T) {
+	var manifest struct {
+		XMLName     xml.Name `xml:"manifest"`
+		Package     string   `xml:"package,attr"`
+		VersionCode int      `xml:"http://schemas.android.com/apk/res/android versionCode,attr"`
+	}
+
+	err := xml.NewTokenDecoder(NewTokenReader(testManifest())).Decode(&manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if manifest.Package != "com.example" || manifest.VersionCode != 7 {
+		t.Errorf("got %+v", manifest)
+	}
+}
@@ -0,0 +1,209 @@
+package axmlParser
+
+import (
+	"io"
+	"strings"
+)
+
+const ANDROID_NAMESPACE = "http://schemas.android.com/apk/res/android"
+
+// A Node is a child of an Element: an *Element, a *Text or a *RawChunk.
+type Node interface{}
+
+// Namespace is a prefix-URI mapping declared on an element.
+type Namespace struct {
+	Prefix, URI string
+}
+
+// Document is a binary XML document held in memory. Prolog and Epilog
+// hold the chunks the decoder does not understand found before and after
+// the root element, the others being children of elements.
+type Document struct {
+	Root           *Element
+	Prolog, Epilog []*RawChunk
+
+	// state while building the tree
+	current    *Element
+	namespaces []Namespace
+}
+
+// Element is an element of a Document. Namespaces lists the mappings whose
+// scope starts at this element, and Children holds its child elements and
+// text nodes in document order.
+type Element struct {
+	Namespace, Name string
+	Attrs           []*Attribute
+	Namespaces      []Namespace
+	Children        []Node
+	Parent          *Element
+	Line            int
+}
+
+// Text is a text node.
+type Text struct {
+	Data string
+	Line int
+}
+
+// ParseDocument builds the tree of a binary XML document.
+func ParseDocument(data []byte) (*Document, error) {
+	doc := new(Document)
+	d := NewDecoder(data)
+	for {
+		token, err := d.Token()
+		if err == io.EOF {
+			return doc, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		doc.addToken(token)
+	}
+}
+
+// addToken grows the tree with the next token of the document.
+func (doc *Document) addToken(token Token) {
+	switch t := token.(type) {
+	case StartNamespace:
+		doc.namespaces = append(doc.namespaces, Namespace{Prefix: t.Prefix, URI: t.URI})
+	case StartElement:
+		elem := &Element{
+			Namespace:  t.Namespace,
+			Name:       t.Name,
+			Attrs:      t.Attrs,
+			Namespaces: doc.namespaces,
+			Children:   make([]Node, 0),
+			Parent:     doc.current,
+			Line:       t.Line,
+		}
+		doc.namespaces = nil
+		if doc.current == nil {
+			if doc.Root == nil {
+				doc.Root = elem
+			}
+		} else {
+			doc.current.Children = append(doc.current.Children, elem)
+		}
+		doc.current = elem
+	case EndElement:
+		if doc.current != nil {
+			doc.current = doc.current.Parent
+		}
+	case CharData:
+		if doc.current != nil {
+			doc.current.Children = append(doc.current.Children, &Text{Data: t.Data, Line: t.Line})
+		}
+	case RawChunk:
+		switch {
+		case doc.current != nil:
+			doc.current.Children = append(doc.current.Children, &t)
+		case doc.Root == nil:
+			doc.Prolog = append(doc.Prolog, &t)
+		default:
+			doc.Epilog = append(doc.Epilog, &t)
+		}
+	}
+}
+
+// FindAll returns the elements matching path, relative to the root
+// element, such as "application/activity".
+func (doc *Document) FindAll(path string) []*Element {
+	if doc.Root == nil {
+		return nil
+	}
+	return doc.Root.FindAll(path)
+}
+
+// Find returns the first element matching path, relative to the root
+// element, or nil.
+func (doc *Document) Find(path string) *Element {
+	if doc.Root == nil {
+		return nil
+	}
+	return doc.Root.Find(path)
+}
+
+// Elements returns the child elements of elem.
+func (elem *Element) Elements() []*Element {
+	elems := make([]*Element, 0)
+	for _, child := range elem.Children {
+		if e, ok := child.(*Element); ok {
+			elems = append(elems, e)
+		}
+	}
+	return elems
+}
+
+// FindAll returns the descendants of elem matching path, a list of local
+// names separated by slashes where "*" matches any name.
+func (elem *Element) FindAll(path string) []*Element {
+	elems := []*Element{elem}
+	for _, name := range strings.Split(path, "/") {
+		if name == "" {
+			continue
+		}
+		var next []*Element
+		for _, e := range elems {
+			for _, child := range e.Elements() {
+				if name == "*" || child.Name == name {
+					next = append(next, child)
+				}
+			}
+		}
+		elems = next
+	}
+	return elems
+}
+
+// Find returns the first descendant of elem matching path, or nil.
+func (elem *Element) Find(path string) *Element {
+	elems := elem.FindAll(path)
+	if len(elems) == 0 {
+		return nil
+	}
+	return elems[0]
+}
+
+// Attr returns the attribute with the given namespace URI and local name,
+// or nil.
+func (elem *Element) Attr(ns, name string) *Attribute {
+	for _, attr := range elem.Attrs {
+		if attr.Namespace == ns && attr.Name == name {
+			return attr
+		}
+	}
+	return nil
+}
+
+// AttrValue returns the value of the attribute with the given namespace
+// URI and local name, or "".
+func (elem *Element) AttrValue(ns, name string) string {
+	if attr := elem.Attr(ns, name); attr != nil {
+		return attr.Value
+	}
+	return ""
+}
+
+// Text returns the concatenated text nodes of elem.
+func (elem *Element) Text() string {
+	var text string
+	for _, child := range elem.Children {
+		if t, ok := child.(*Text); ok {
+			text += t.Data
+		}
+	}
+	return text
+}
+
+// LookupPrefix returns the prefix mapped to a namespace URI in the scope
+// of elem.
+func (elem *Element) LookupPrefix(uri string) (string, bool) {
+	for e := elem; e != nil; e = e.Parent {
+		for _, ns := range e.Namespaces {
+			if ns.URI == uri {
+				return ns.Prefix, true
+			}
+		}
+	}
+	return "", false
+}
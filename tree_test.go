@@ -0,0 +1,72 @@
+package axmlParser
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseDocument(t *testing.T) {
+	doc, err := ParseDocument(testManifest())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	listener := new(TreeListener)
+	if err = New(listener).Parse(testManifest()); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, doc := range []*Document{doc, listener.Document} {
+		root := doc.Root
+		if root == nil || root.Name != "manifest" {
+			t.Fatalf("root = %+v, want manifest", root)
+		}
+		if len(root.Namespaces) != 1 || root.Namespaces[0].URI != ANDROID_NAMESPACE {
+			t.Errorf("namespaces = %+v", root.Namespaces)
+		}
+		if v := root.AttrValue("", "package"); v != "com.example" {
+			t.Errorf("package = %q", v)
+		}
+		if v := root.AttrValue(ANDROID_NAMESPACE, "versionCode"); v != "7" {
+			t.Errorf("versionCode = %q", v)
+		}
+		if prefix, _ := root.LookupPrefix(ANDROID_NAMESPACE); prefix != "android" {
+			t.Errorf("prefix = %q", prefix)
+		}
+		if elems := doc.FindAll("application/activity"); len(elems) != 0 {
+			t.Errorf("FindAll = %v, want none", elems)
+		}
+	}
+}
+
+func TestWriteXML(t *testing.T) {
+	root := &Element{
+		Name:       "manifest",
+		Namespaces: []Namespace{{Prefix: "android", URI: ANDROID_NAMESPACE}},
+		Attrs:      []*Attribute{{Name: "package", Value: "com.example"}},
+	}
+	app := &Element{Name: "application", Parent: root, Attrs: []*Attribute{
+		{Name: "label", Namespace: ANDROID_NAMESPACE, Value: `Tom & "Jerry"`},
+		{Name: "theme", Namespace: "http://example.com/res", Value: "@0x7f0e0001"},
+	}}
+	meta := &Element{Name: "meta-data", Parent: app}
+	meta.Children = []Node{&Text{Data: "a<b"}}
+	app.Children = []Node{meta}
+	root.Children = []Node{app}
+
+	var b strings.Builder
+	if err := (&Document{Root: root}).WriteXML(&b, "    "); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `<?xml version="1.0" encoding="utf-8"?>
+<manifest xmlns:android="http://schemas.android.com/apk/res/android" xmlns:ns0="http://example.com/res" package="com.example">
+    <application android:label="Tom &amp; &quot;Jerry&quot;" ns0:theme="@0x7f0e0001">
+        <meta-data>a&lt;b</meta-data>
+    </application>
+</manifest>
+`
+	if got := b.String(); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
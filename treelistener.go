@@ -0,0 +1,76 @@
+package axmlParser
+
+// TreeListener builds a Document from the parser callbacks. Listener
+// callbacks carry no line numbers, use ParseDocument to keep them.
+type TreeListener struct {
+	Document *Document
+}
+
+func (listener *TreeListener) StartDocument() {
+	listener.Document = new(Document)
+}
+
+// document returns the document being built, even if the data had no
+// document chunk to trigger StartDocument.
+func (listener *TreeListener) document() *Document {
+	if listener.Document == nil {
+		listener.Document = new(Document)
+	}
+	return listener.Document
+}
+
+/**
+ * Receive notification of the end of a document.
+ */
+func (listener *TreeListener) EndDocument() {
+}
+
+/**
+ * Begin the scope of a prefix-URI Namespace mapping.
+ */
+func (listener *TreeListener) StartPrefixMapping(prefix, uri string) {
+	listener.document().addToken(StartNamespace{Prefix: prefix, URI: uri})
+}
+
+/**
+ * End the scope of a prefix-URI mapping.
+ */
+func (listener *TreeListener) EndPrefixMapping(prefix, uri string) {}
+
+/**
+ * Receive notification of the beginning of an element.
+ */
+func (listener *TreeListener) StartElement(uri, localName, qName string,
+	attrs []*Attribute) {
+	listener.document().addToken(StartElement{Namespace: uri, Name: localName,
+		QName: qName, Attrs: attrs})
+}
+
+/**
+ * Receive notification of the end of an element.
+ */
+func (listener *TreeListener) EndElement(uri, localName, qName string) {
+	listener.document().addToken(EndElement{Namespace: uri, Name: localName,
+		QName: qName})
+}
+
+/**
+ * Receive notification of text.
+ */
+func (listener *TreeListener) Text(data string) {
+	listener.CharacterData(data)
+}
+
+/**
+ * Receive notification of character data (in a <![CDATA[ ]]> block).
+ */
+func (listener *TreeListener) CharacterData(data string) {
+	listener.document().addToken(CharData{Data: data})
+}
+
+/**
+ * Receive notification of a processing instruction.
+ */
+func (listener *TreeListener) ProcessingInstruction(target, data string) {
+
+}
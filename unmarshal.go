@@ -0,0 +1,287 @@
+package axmlParser
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var (
+	attributeType = reflect.TypeOf((*Attribute)(nil))
+	elementType   = reflect.TypeOf((*Element)(nil))
+)
+
+// Unmarshal parses a binary XML document and stores the root element into
+// the struct pointed to by v, in the spirit of encoding/xml.Unmarshal.
+//
+// Only fields with an axml tag, and untagged embedded structs, are filled.
+// The tag is a path of element names separated by '>', relative to the
+// element being decoded, optionally followed by a flag:
+//
+//	Activities []Activity `axml:"application>activity"`
+//	Name       string     `axml:"android:name,attr"`
+//	Perms      []string   `axml:"uses-permission>android:name,attr"`
+//	Text       string     `axml:",chardata"`
+//
+// With ",attr" the last name of the path is an attribute. Attributes are
+// matched by namespace URI, whatever prefix the document binds it to: the
+// "android" prefix stands for ANDROID_NAMESPACE, and other namespaces are
+// given by URI followed by a space, as in encoding/xml:
+//
+//	Custom string `axml:"http://schemas.android.com/apk/res-auto icon,attr"`
+//
+// Numeric and boolean fields are filled from the typed
+// value of attributes rather than from their formatted string, and are left
+// untouched by references, which need a resource table to be resolved.
+// Fields of type *Attribute and *Element receive the matching node itself.
+func Unmarshal(data []byte, v interface{}) error {
+	doc, err := ParseDocument(data)
+	if err != nil {
+		return err
+	}
+	if doc.Root == nil {
+		return errNoRoot
+	}
+	return doc.Root.Unmarshal(v)
+}
+
+// Unmarshal stores elem into the struct pointed to by v, see Unmarshal.
+func (elem *Element) Unmarshal(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return errors.New("axmlParser: Unmarshal needs a non-nil pointer")
+	}
+	return setElement(rv.Elem(), elem)
+}
+
+func unmarshalStruct(elem *Element, v reflect.Value) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag, ok := field.Tag.Lookup("axml")
+		if !ok && field.Anonymous && field.Type.Kind() == reflect.Struct {
+			// untagged embedded structs share the element
+			if err := unmarshalStruct(elem, v.Field(i)); err != nil {
+				return err
+			}
+			continue
+		}
+		if !ok || tag == "-" || field.PkgPath != "" {
+			continue
+		}
+		if err := unmarshalField(elem, v.Field(i), tag); err != nil {
+			return fmt.Errorf("axmlParser: field %s: %w", field.Name, err)
+		}
+	}
+	return nil
+}
+
+func unmarshalField(elem *Element, v reflect.Value, tag string) error {
+	path, flag := tag, ""
+	if i := strings.Index(tag, ","); i >= 0 {
+		path, flag = tag[:i], tag[i+1:]
+	}
+	names := strings.Split(path, ">")
+
+	switch flag {
+	case "attr":
+		last := len(names) - 1
+		uri, name, err := splitAttrName(names[last])
+		if err != nil {
+			return err
+		}
+		elems := elem.FindAll(strings.Join(names[:last], "/"))
+		attrs := make([]*Attribute, 0)
+		for _, e := range elems {
+			if attr := e.Attr(uri, name); attr != nil {
+				attrs = append(attrs, attr)
+			}
+		}
+		return setAttrs(v, attrs)
+	case "chardata":
+		return setText(v, elem.Text())
+	case "":
+		return setElements(v, elem.FindAll(strings.Join(names, "/")))
+	}
+	return fmt.Errorf("unknown tag flag %q", flag)
+}
+
+// splitAttrName returns the namespace URI and local name of an attribute in a
+// tag, such as "android:name", "package" or "http://example.com name".
+func splitAttrName(qname string) (string, string, error) {
+	if i := strings.LastIndex(qname, " "); i >= 0 {
+		return qname[:i], qname[i+1:], nil
+	}
+	i := strings.Index(qname, ":")
+	if i < 0 {
+		return "", qname, nil
+	}
+	if qname[:i] != "android" {
+		return "", "", fmt.Errorf("unknown namespace prefix %q, give the namespace URI instead", qname[:i])
+	}
+	return ANDROID_NAMESPACE, qname[i+1:], nil
+}
+
+func isList(v reflect.Value) bool {
+	return v.Kind() == reflect.Slice && v.Type().Elem().Kind() != reflect.Uint8
+}
+
+func setElements(v reflect.Value, elems []*Element) error {
+	if !isList(v) {
+		if len(elems) == 0 {
+			return nil
+		}
+		return setElement(v, elems[0])
+	}
+
+	list := reflect.MakeSlice(v.Type(), len(elems), len(elems))
+	for i, elem := range elems {
+		if err := setElement(list.Index(i), elem); err != nil {
+			return err
+		}
+	}
+	v.Set(list)
+	return nil
+}
+
+func setElement(v reflect.Value, elem *Element) error {
+	switch {
+	case v.Type() == elementType:
+		v.Set(reflect.ValueOf(elem))
+		return nil
+	case v.Kind() == reflect.Ptr:
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return setElement(v.Elem(), elem)
+	case v.Kind() == reflect.Struct:
+		return unmarshalStruct(elem, v)
+	}
+	return setText(v, elem.Text())
+}
+
+func setAttrs(v reflect.Value, attrs []*Attribute) error {
+	if !isList(v) {
+		if len(attrs) == 0 {
+			return nil
+		}
+		return setAttr(v, attrs[0])
+	}
+
+	list := reflect.MakeSlice(v.Type(), len(attrs), len(attrs))
+	for i, attr := range attrs {
+		if err := setAttr(list.Index(i), attr); err != nil {
+			return err
+		}
+	}
+	v.Set(list)
+	return nil
+}
+
+// setAttr fills v from the typed value of attr, falling back to parsing
+// its string for attributes stored as strings.
+func setAttr(v reflect.Value, attr *Attribute) error {
+	if v.Type() == attributeType {
+		v.Set(reflect.ValueOf(attr))
+		return nil
+	}
+	if attr.Type == RES_TYPE_STRING {
+		return setText(v, attr.Value)
+	}
+	if _, err := attr.ResourceID(); err == nil && v.Kind() != reflect.String {
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return setAttr(v.Elem(), attr)
+	case reflect.String:
+		v.SetString(attr.Value)
+	case reflect.Bool:
+		b, err := attr.Bool()
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := attr.Int()
+		if err != nil {
+			return err
+		}
+		if v.OverflowInt(int64(i)) {
+			return overflowError(attr, v)
+		}
+		v.SetInt(int64(i))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if _, err := attr.Int(); err != nil {
+			return err
+		}
+		if v.OverflowUint(uint64(uint32(attr.Data))) {
+			return overflowError(attr, v)
+		}
+		v.SetUint(uint64(uint32(attr.Data)))
+	case reflect.Float32, reflect.Float64:
+		switch attr.Type {
+		case RES_TYPE_DIMENSION, RES_TYPE_FRACTION:
+			v.SetFloat(float64(ComplexToFloat(attr.Data)))
+		default:
+			f, err := attr.Float()
+			if err != nil {
+				return err
+			}
+			v.SetFloat(float64(f))
+		}
+	default:
+		return fmt.Errorf("cannot store attribute %s in %s", attr.Name, v.Type())
+	}
+	return nil
+}
+
+func overflowError(attr *Attribute, v reflect.Value) error {
+	return fmt.Errorf("value %s of attribute %s overflows %s", attr.Value, attr.Name, v.Type())
+}
+
+// setText fills v by parsing s.
+func setText(v reflect.Value, s string) error {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return setText(v.Elem(), s)
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(strings.TrimSpace(s))
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(strings.TrimSpace(s), 0, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		i, err := strconv.ParseUint(strings.TrimSpace(s), 0, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(i)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(strings.TrimSpace(s), v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	default:
+		return fmt.Errorf("cannot store text in %s", v.Type())
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+package axmlParser
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestUnmarshal(t *testing.T) {
+	var manifest struct {
+		Package     string     `axml:"package,attr"`
+		VersionCode int64      `axml:"android:versionCode,attr"`
+		Code        *Attribute `axml:"android:versionCode,attr"`
+		CodeText    string     `axml:"android:versionCode,attr"`
+		Missing     []string   `axml:"uses-permission>android:name,attr"`
+		Application *struct {
+			Label string `axml:"android:label,attr"`
+		} `axml:"application"`
+	}
+
+	if err := Unmarshal(testManifest(), &manifest); err != nil {
+		t.Fatal(err)
+	}
+	if manifest.Package != "com.example" || manifest.VersionCode != 7 || manifest.CodeText != "7" {
+		t.Errorf("got %+v", manifest)
+	}
+	if manifest.Code == nil || manifest.Code.Type != RES_TYPE_INT_DEC {
+		t.Errorf("Code = %+v", manifest.Code)
+	}
+	if len(manifest.Missing) != 0 || manifest.Application != nil {
+		t.Errorf("got values for missing nodes: %+v", manifest)
+	}
+
+	var wrongType struct {
+		Code bool `axml:"android:versionCode,attr"`
+	}
+	if err := Unmarshal(testManifest(), &wrongType); !errors.Is(err, ErrValueType) {
+		t.Errorf("Unmarshal int into bool: error = %v, want ErrValueType", err)
+	}
+
+	data := binaryXML(t, `<manifest xmlns:android="`+ANDROID_NAMESPACE+`" android:versionCode="300"/>`)
+	var small struct {
+		Code int8 `axml:"android:versionCode,attr"`
+	}
+	if err := Unmarshal(data, &small); err == nil {
+		t.Errorf("Unmarshal 300 into int8 gave %d", small.Code)
+	}
+	var unsigned struct {
+		Code uint8 `axml:"android:versionCode,attr"`
+	}
+	if err := Unmarshal(data, &unsigned); err == nil {
+		t.Errorf("Unmarshal 300 into uint8 gave %d", unsigned.Code)
+	}
+	var fits struct {
+		Code uint16 `axml:"android:versionCode,attr"`
+	}
+	if err := Unmarshal(data, &fits); err != nil || fits.Code != 300 {
+		t.Errorf("Unmarshal 300 into uint16 = %d, %v", fits.Code, err)
+	}
+}
+
+func TestParseManifestModel(t *testing.T) {
+	manifest, err := ParseManifest(testManifest())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if manifest.Package != "com.example" || manifest.VersionCode != 7 {
+		t.Errorf("got %+v", manifest)
+	}
+	if len(manifest.UsesPermissions) != 0 || len(manifest.Application.Activities) != 0 {
+		t.Errorf("got components for an empty manifest: %+v", manifest)
+	}
+
+	manifest, err = ParseManifest(binaryXML(t, `<manifest xmlns:android="`+ANDROID_NAMESPACE+`"
+    package="com.example" android:versionCode="3" android:versionCodeMajor="2">
+  <uses-sdk android:minSdkVersion="Q" android:targetSdkVersion="34"/>
+  <uses-permission android:name="android.permission.INTERNET"/>
+  <uses-permission android:name="android.permission.CAMERA" android:maxSdkVersion="28"/>
+  <application android:label="Example">
+    <activity android:name=".Main" android:exported="true">
+      <intent-filter android:priority="3">
+        <action android:name="android.intent.action.VIEW"/>
+        <category android:name="android.intent.category.DEFAULT"/>
+        <data android:scheme="https" android:host="example.com"/>
+      </intent-filter>
+    </activity>
+    <service android:name=".Sync" android:enabled="false"/>
+    <provider android:name=".Files" android:authorities="com.example.files"/>
+  </application>
+</manifest>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if manifest.VersionCode != 2<<32|3 {
+		t.Errorf("VersionCode = 0x%x, want 0x200000003", manifest.VersionCode)
+	}
+	if sdk := manifest.UsesSdk; sdk.Min != 0 || sdk.MinCodename != "Q" || sdk.Target != 34 || sdk.TargetCodename != "" {
+		t.Errorf("UsesSdk = %+v", sdk)
+	}
+	if perms := manifest.UsesPermissions; len(perms) != 2 || perms[0].Name != "android.permission.INTERNET" ||
+		perms[1].MaxSdkVersion != 28 {
+		t.Errorf("UsesPermissions = %+v", perms)
+	}
+	app := manifest.Application
+	if len(app.Activities) != 1 || app.Activities[0].Name != ".Main" || !*app.Activities[0].Exported {
+		t.Fatalf("Activities = %+v", app.Activities)
+	}
+	filter := app.Activities[0].IntentFilters[0]
+	if filter.Priority != 3 || filter.Actions[0] != "android.intent.action.VIEW" ||
+		filter.Categories[0] != "android.intent.category.DEFAULT" || filter.Data[0].Host != "example.com" {
+		t.Errorf("IntentFilter = %+v", filter)
+	}
+	if len(app.Services) != 1 || *app.Services[0].Enabled || app.Providers[0].Authorities != "com.example.files" {
+		t.Errorf("Services = %+v, Providers = %+v", app.Services, app.Providers)
+	}
+}
+
+func TestUnmarshalNamespacePrefix(t *testing.T) {
+	// obfuscated manifests bind the android namespace to other prefixes
+	data := binaryXML(t, `<manifest xmlns:a="`+ANDROID_NAMESPACE+`"
+    xmlns:app="http://schemas.android.com/apk/res-auto"
+    package="com.example" a:versionCode="5" a:versionName="1.5" app:flavor="free">
+  <application>
+    <activity a:name=".Main">
+      <intent-filter>
+        <action a:name="android.intent.action.MAIN"/>
+        <category a:name="android.intent.category.LAUNCHER"/>
+      </intent-filter>
+    </activity>
+  </application>
+</manifest>`)
+
+	manifest, err := ParseManifest(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if manifest.VersionName != "1.5" || manifest.VersionCode != 5 {
+		t.Errorf("manifest = %+v", manifest)
+	}
+	if launcher := manifest.LauncherActivity(); launcher == nil || launcher.Name != "com.example.Main" {
+		t.Errorf("LauncherActivity() = %+v", launcher)
+	}
+
+	var custom struct {
+		Flavor string `axml:"http://schemas.android.com/apk/res-auto flavor,attr"`
+	}
+	if err := Unmarshal(data, &custom); err != nil || custom.Flavor != "free" {
+		t.Errorf("Flavor = %q, %v", custom.Flavor, err)
+	}
+	var unknown struct {
+		Flavor string `axml:"app:flavor,attr"`
+	}
+	if err := Unmarshal(data, &unknown); err == nil {
+		t.Error("Unmarshal with an unknown prefix succeeded")
+	}
+
+	listener := new(AppNameListener)
+	if err := New(listener).Parse(data); err != nil {
+		t.Fatal(err)
+	}
+	if listener.ActivityName != "com.example.Main" || listener.Err != nil {
+		t.Errorf("listener = %+v", listener)
+	}
+}
@@ -0,0 +1,70 @@
+package axmlParser
+
+import "testing"
+
+func TestAttributeValueFormat(t *testing.T) {
+	parser := New(nil)
+	parser.StringsTable = []string{"hello"}
+	parser.StringsCount = 1
+
+	tests := []struct {
+		tpe, data int
+		want      string
+	}{
+		{RES_TYPE_NULL, DATA_NULL_UNDEFINED, "@null"},
+		{RES_TYPE_NULL, DATA_NULL_EMPTY, "@empty"},
+		{RES_TYPE_REFERENCE, 0, "@null"},
+		{RES_TYPE_REFERENCE, 0x7f040001, "@0x7f040001"},
+		{RES_TYPE_DYNAMIC_REFERENCE, 0x7f040001, "@0x7f040001"},
+		{RES_TYPE_ATTRIBUTE, 0x01010036, "?0x01010036"},
+		{RES_TYPE_DYNAMIC_ATTRIBUTE, 0x7f010002, "?0x7f010002"},
+		{RES_TYPE_STRING, 0, "hello"},
+		{RES_TYPE_FLOAT, 0x3fc00000, "1.5"},
+		{RES_TYPE_INT_DEC, 21, "21"},
+		{RES_TYPE_INT_DEC, 0xFFFFFFFF, "-1"},
+		{RES_TYPE_INT_HEX, 0x30, "0x00000030"},
+		{RES_TYPE_INT_BOOLEAN, 0xFFFFFFFF, "true"},
+		{RES_TYPE_INT_BOOLEAN, 0, "false"},
+		{RES_TYPE_INT_COLOR_ARGB8, 0x80ff0000, "#80ff0000"},
+		{RES_TYPE_INT_COLOR_RGB8, 0xff00ff00, "#00ff00"},
+		{RES_TYPE_INT_COLOR_ARGB4, 0x88ff0000, "#8f00"},
+		{RES_TYPE_INT_COLOR_RGB4, 0xff0000ff, "#00f"},
+		{0x20, 1, "(unknown 0x20) 0x00000001"},
+	}
+	for _, test := range tests {
+		if got := parser.getAttributeValue(test.tpe, test.data); got != test.want {
+			t.Errorf("getAttributeValue(0x%02x, 0x%08x) = %q, want %q",
+				test.tpe, test.data, got, test.want)
+		}
+	}
+}
+
+func TestComplexValues(t *testing.T) {
+	tests := []struct {
+		tpe, data int
+		want      string
+	}{
+		{RES_TYPE_DIMENSION, 0x00001001, "16dp"},
+		{RES_TYPE_DIMENSION, 0x0000C011, "1.5dp"},
+		{RES_TYPE_DIMENSION, 0x00000E02, "14sp"},
+		{RES_TYPE_DIMENSION, 0xFFFFFF00, "-1px"},
+		{RES_TYPE_DIMENSION, 0x00004015, "0.5mm"},
+		{RES_TYPE_DIMENSION, 0x00000109, "1(unit 0x9)"},
+		{RES_TYPE_FRACTION, 0x40000030, "50%"},
+		{RES_TYPE_FRACTION, 0x20000031, "25%p"},
+		{RES_TYPE_FRACTION, 0x00006430, "0.0011920929%"},
+		{RES_TYPE_FRACTION, 0x00000100, "100%"},
+	}
+
+	parser := New(nil)
+	for _, test := range tests {
+		if got := parser.getAttributeValue(test.tpe, test.data); got != test.want {
+			t.Errorf("getAttributeValue(0x%02x, 0x%08x) = %q, want %q",
+				test.tpe, test.data, got, test.want)
+		}
+	}
+
+	if got := ComplexToFloat(0x0000C011); got != 1.5 {
+		t.Errorf("ComplexToFloat(0x0000C011) = %v, want 1.5", got)
+	}
+}
@@ -0,0 +1,177 @@
+package axmlParser
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// xmlPrinter writes a Document as text XML.
+type xmlPrinter struct {
+	*bufio.Writer
+	indent string
+
+	// prefixes for namespace URIs used but never declared, as found in
+	// stripped manifests; they get declared on the root element
+	undeclared map[string]string
+	extraNs    []Namespace
+}
+
+// WriteXML writes doc as text XML, with nested elements on their own lines
+// indented by indent. An empty indent writes everything on one line.
+func (doc *Document) WriteXML(w io.Writer, indent string) error {
+	p := &xmlPrinter{
+		Writer:     bufio.NewWriter(w),
+		indent:     indent,
+		undeclared: make(map[string]string),
+	}
+
+	p.WriteString(`<?xml version="1.0" encoding="utf-8"?>`)
+	if doc.Root != nil {
+		p.extraNs = undeclaredNamespaces(doc.Root)
+		for _, ns := range p.extraNs {
+			p.undeclared[ns.URI] = ns.Prefix
+		}
+		p.newline(0)
+		p.writeElement(doc.Root, 0)
+	}
+	p.WriteString("\n")
+	return p.Flush()
+}
+
+// undeclaredNamespaces assigns a prefix to every namespace URI used by root
+// or its descendants without a declaration in scope, for declaring them on
+// root.
+func undeclaredNamespaces(root *Element) []Namespace {
+	namespaces := make([]Namespace, 0)
+	seen := make(map[string]bool)
+	declare := func(elem *Element, uri string) {
+		if uri == "" || seen[uri] {
+			return
+		}
+		if _, ok := elem.LookupPrefix(uri); ok {
+			return
+		}
+
+		prefix := fmt.Sprintf("ns%d", len(namespaces))
+		if uri == ANDROID_NAMESPACE {
+			prefix = "android"
+		}
+		seen[uri] = true
+		namespaces = append(namespaces, Namespace{Prefix: prefix, URI: uri})
+	}
+
+	var walk func(elem *Element)
+	walk = func(elem *Element) {
+		declare(elem, elem.Namespace)
+		for _, attr := range elem.Attrs {
+			declare(elem, attr.Namespace)
+		}
+		for _, child := range elem.Elements() {
+			walk(child)
+		}
+	}
+	walk(root)
+	return namespaces
+}
+
+// qualify returns name prefixed for the namespace uri in the scope of elem.
+func (p *xmlPrinter) qualify(elem *Element, uri, name string) string {
+	if uri == "" {
+		return name
+	}
+	prefix, ok := elem.LookupPrefix(uri)
+	if !ok {
+		prefix = p.undeclared[uri]
+	}
+	if prefix == "" {
+		return name
+	}
+	return prefix + ":" + name
+}
+
+func (p *xmlPrinter) newline(depth int) {
+	if p.indent == "" {
+		return
+	}
+	p.WriteString("\n")
+	p.WriteString(strings.Repeat(p.indent, depth))
+}
+
+func (p *xmlPrinter) writeElement(elem *Element, depth int) {
+	name := p.qualify(elem, elem.Namespace, elem.Name)
+	p.WriteString("<" + name)
+
+	namespaces := elem.Namespaces
+	if elem.Parent == nil {
+		namespaces = append(append([]Namespace{}, namespaces...), p.extraNs...)
+	}
+	for _, ns := range namespaces {
+		if ns.Prefix == "" {
+			p.writeAttr("xmlns", ns.URI)
+		} else {
+			p.writeAttr("xmlns:"+ns.Prefix, ns.URI)
+		}
+	}
+	for _, attr := range elem.Attrs {
+		p.writeAttr(p.qualify(elem, attr.Namespace, attr.Name), attr.Value)
+	}
+
+	if len(elem.Children) == 0 {
+		p.WriteString("/>")
+		return
+	}
+	p.WriteString(">")
+
+	// indenting would change the text, so mixed content is written as is
+	hasText := false
+	for _, child := range elem.Children {
+		if _, ok := child.(*Text); ok {
+			hasText = true
+		}
+	}
+
+	for _, child := range elem.Children {
+		switch c := child.(type) {
+		case *Element:
+			if !hasText {
+				p.newline(depth + 1)
+			}
+			p.writeElement(c, depth+1)
+		case *Text:
+			p.WriteString(escapeText(c.Data, false))
+		}
+	}
+
+	if !hasText {
+		p.newline(depth)
+	}
+	p.WriteString("</" + name + ">")
+}
+
+func (p *xmlPrinter) writeAttr(name, value string) {
+	p.WriteString(" " + name + `="` + escapeText(value, true) + `"`)
+}
+
+// escapeText escapes s for use in text, or in a quoted attribute value.
+func escapeText(s string, attr bool) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '&':
+			b.WriteString("&amp;")
+		case r == '<':
+			b.WriteString("&lt;")
+		case r == '>':
+			b.WriteString("&gt;")
+		case r == '"' && attr:
+			b.WriteString("&quot;")
+		case (r == '\n' || r == '\r' || r == '\t') && attr:
+			fmt.Fprintf(&b, "&#%d;", r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}